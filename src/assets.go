@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// PresenceImage is one image of the activity and the text shown when
+// hovering it.
+type PresenceImage struct {
+	Image string `json:"image"` // Asset key of the Discord application, or an https:// URL
+	Text  string `json:"text"`
+}
+
+// PresenceAssets are the images and hover texts used for each kind of
+// activity. Teams with their own Discord application point these at their
+// own artwork.
+type PresenceAssets struct {
+	Large     PresenceImage `json:"large"`     // Shown for every activity
+	Editing   PresenceImage `json:"editing"`   // Small image while editing a design file
+	Browsing  PresenceImage `json:"browsing"`  // Small image in Home and Drafts
+	DevMode   PresenceImage `json:"dev_mode"`  // Small image in Dev Mode
+	Prototype PresenceImage `json:"prototype"` // Small image while presenting
+	FigJam    PresenceImage `json:"figjam"`    // Small image in FigJam
+	Idle      PresenceImage `json:"idle"`      // Small image while idle
+}
+
+// DefaultPresenceAssets returns the artwork of the bundled Discord application.
+// It only has "largeimageid", "edit" and "folder", so the other modes reuse
+// the editing image and tell themselves apart by the hover text.
+func DefaultPresenceAssets() PresenceAssets {
+	return PresenceAssets{
+		Large:     PresenceImage{Image: "largeimageid", Text: "Figma"},
+		Editing:   PresenceImage{Image: "edit", Text: "Editing"},
+		Browsing:  PresenceImage{Image: "folder", Text: "Browsing"},
+		DevMode:   PresenceImage{Image: "edit", Text: "Dev Mode"},
+		Prototype: PresenceImage{Image: "edit", Text: "Presenting"},
+		FigJam:    PresenceImage{Image: "edit", Text: "FigJam"},
+		Idle:      PresenceImage{Image: "edit", Text: "Away"},
+	}
+}
+
+// forMode returns the small image for a Figma mode.
+func (a PresenceAssets) forMode(mode FigmaMode) PresenceImage {
+	switch mode {
+	case ModeHome, ModeDrafts:
+		return a.Browsing
+	case ModeDevMode:
+		return a.DevMode
+	case ModePrototype:
+		return a.Prototype
+	case ModeFigJam:
+		return a.FigJam
+	default:
+		return a.Editing
+	}
+}
+
+type namedImage struct {
+	name  string // JSON key, for messages
+	image *PresenceImage
+}
+
+// images lists every image in a fixed order.
+func (a *PresenceAssets) images() []namedImage {
+	return []namedImage{
+		{"large", &a.Large},
+		{"editing", &a.Editing},
+		{"browsing", &a.Browsing},
+		{"dev_mode", &a.DevMode},
+		{"prototype", &a.Prototype},
+		{"figjam", &a.FigJam},
+		{"idle", &a.Idle},
+	}
+}
+
+// validate reports the first invalid image or text.
+func (a PresenceAssets) validate() error {
+	for _, named := range a.images() {
+		if err := validateAssetImage(named.image.Image); err != nil {
+			return fmt.Errorf("assets.%s.image: %w", named.name, err)
+		}
+		if err := validateAssetText(named.image.Text); err != nil {
+			return fmt.Errorf("assets.%s.text: %w", named.name, err)
+		}
+	}
+	return nil
+}
+
+// sanitize replaces invalid values with the defaults.
+func (a *PresenceAssets) sanitize() {
+	defaults := DefaultPresenceAssets()
+	fallback := defaults.images()
+	for i, named := range a.images() {
+		if validateAssetImage(named.image.Image) != nil {
+			named.image.Image = fallback[i].image.Image
+		}
+		if validateAssetText(named.image.Text) != nil {
+			named.image.Text = fallback[i].image.Text
+		}
+	}
+}
+
+// assetKeyPattern matches the names Discord gives uploaded art assets.
+var assetKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]{1,128}$`)
+
+// maxAssetURLLength is Discord's limit for external image URLs.
+const maxAssetURLLength = 256
+
+// validateAssetImage accepts an application asset key or an https:// URL.
+func validateAssetImage(image string) error {
+	if !strings.Contains(image, "://") {
+		if !assetKeyPattern.MatchString(image) {
+			return fmt.Errorf("%q is neither an asset key (letters, digits, _ . -) nor an https:// URL", image)
+		}
+		return nil
+	}
+
+	if len(image) > maxAssetURLLength {
+		return fmt.Errorf("image URL is longer than %d characters", maxAssetURLLength)
+	}
+	parsed, err := url.Parse(image)
+	if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+		return fmt.Errorf("%q is not an https:// URL", image)
+	}
+	return nil
+}
+
+// validateAssetText accepts hover texts Discord will show: empty, or 2 to 128
+// characters.
+func validateAssetText(text string) error {
+	n := utf8.RuneCountInString(text)
+	if n == 0 || (n >= 2 && n <= 128) {
+		return nil
+	}
+	return fmt.Errorf("text must be empty or 2 to 128 characters, got %d", n)
+}
+
+// discordSnowflakePattern matches Discord application IDs.
+var discordSnowflakePattern = regexp.MustCompile(`^[0-9]{17,20}$`)
+
+// validateClientID checks the shape of a Discord application ID.
+func validateClientID(id string) error {
+	if !discordSnowflakePattern.MatchString(id) {
+		return fmt.Errorf("%q is not a Discord application ID (17 to 20 digits)", id)
+	}
+	return nil
+}
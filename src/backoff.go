@@ -0,0 +1,40 @@
+package main
+
+import (
+	"math/rand"
+	"time"
+)
+
+const (
+	reconnectBaseDelay = 1 * time.Second
+	reconnectMaxDelay  = 60 * time.Second
+)
+
+// reconnectBackoff produces exponentially growing delays between attempts to
+// reach Discord, with jitter so several clients don't retry in lockstep. Each
+// connection attempt starts with a fresh one.
+type reconnectBackoff struct {
+	base    time.Duration
+	max     time.Duration
+	attempt int
+	jitter  func() float64 // Returns a value in [0, 1)
+}
+
+func newReconnectBackoff() *reconnectBackoff {
+	return &reconnectBackoff{base: reconnectBaseDelay, max: reconnectMaxDelay, jitter: rand.Float64}
+}
+
+// Next returns the delay before the next attempt: half of the exponential
+// step plus a random share of the other half, capped at max.
+func (b *reconnectBackoff) Next() time.Duration {
+	delay := b.max
+	if b.attempt < 16 {
+		if step := b.base << b.attempt; step < b.max {
+			delay = step
+		}
+	}
+	b.attempt++
+
+	half := delay / 2
+	return half + time.Duration(b.jitter()*float64(delay-half))
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+
+	"Figma-Discord-Rich-Presence/discordipc"
+)
+
+const (
+	maxPresenceButtons  = 2   // Discord shows at most two buttons
+	maxButtonLabelRunes = 32  // Discord's limit for button labels
+	maxButtonURLLength  = 512 // Discord's limit for button URLs
+)
+
+// PresenceButton is a link button under the activity. Discord shows buttons
+// to other people only, never on your own profile.
+//
+// URL is a text/template with the current file as {{.File}} and the view as
+// {{.Mode}}, for example https://www.figma.com/files/search?q={{urlquery .File}}.
+type PresenceButton struct {
+	Label         string `json:"label"`
+	URL           string `json:"url"`
+	ShowInPrivacy bool   `json:"show_in_privacy_mode,omitempty"` // Keep the button while privacy mode is on; {{.File}} is then empty
+}
+
+// buttonTemplateData is what a button URL template can refer to.
+type buttonTemplateData struct {
+	File string
+	Mode string
+}
+
+// validateButtons checks the count, every label and every URL template.
+func validateButtons(buttons []PresenceButton) error {
+	if len(buttons) > maxPresenceButtons {
+		return fmt.Errorf("at most %d buttons are allowed, got %d", maxPresenceButtons, len(buttons))
+	}
+	for i, button := range buttons {
+		if err := button.validate(); err != nil {
+			return fmt.Errorf("buttons[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (b PresenceButton) validate() error {
+	label := strings.TrimSpace(b.Label)
+	if label == "" {
+		return fmt.Errorf("label is empty")
+	}
+	if n := utf8.RuneCountInString(label); n > maxButtonLabelRunes {
+		return fmt.Errorf("label %q is %d characters, the limit is %d", label, n, maxButtonLabelRunes)
+	}
+
+	lower := strings.ToLower(strings.TrimSpace(b.URL))
+	if !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(lower, "http://") {
+		return fmt.Errorf("url %q must start with https:// or http://", b.URL)
+	}
+	for _, data := range b.sampleData() {
+		if _, err := renderButtonURL(b.URL, data); err != nil {
+			return fmt.Errorf("url template: %w", err)
+		}
+	}
+	return nil
+}
+
+// sampleData stands in for the Figma states a button URL is rendered with:
+// a file, and for buttons kept in privacy mode also no file.
+func (b PresenceButton) sampleData() []buttonTemplateData {
+	data := []buttonTemplateData{{File: "Landing Page Redesign", Mode: ModeEditing.String()}}
+	if b.ShowInPrivacy {
+		data = append(data, buttonTemplateData{Mode: ModeEditing.String()})
+	}
+	return data
+}
+
+// sanitizeButtons drops invalid buttons and any beyond the limit.
+func sanitizeButtons(buttons []PresenceButton) []PresenceButton {
+	var valid []PresenceButton
+	for _, button := range buttons {
+		if len(valid) == maxPresenceButtons {
+			break
+		}
+		if button.validate() == nil {
+			valid = append(valid, button)
+		}
+	}
+	return valid
+}
+
+// renderButtons fills in the URL templates for the current Figma state. In
+// privacy mode only buttons marked ShowInPrivacy are kept, and they don't get
+// the file name. Templates are checked when the config is saved, so a URL
+// that still comes out invalid, e.g. too long for a long file name, just
+// leaves its button out.
+func renderButtons(buttons []PresenceButton, figma FigmaState, privacyMode bool) []discordipc.Button {
+	data := buttonTemplateData{File: figma.File, Mode: figma.Mode.String()}
+	if privacyMode {
+		data.File = ""
+	}
+
+	var out []discordipc.Button
+	for _, button := range buttons {
+		if privacyMode && !button.ShowInPrivacy {
+			continue
+		}
+
+		link, err := renderButtonURL(button.URL, data)
+		if err != nil {
+			continue
+		}
+		out = append(out, discordipc.Button{Label: strings.TrimSpace(button.Label), URL: link})
+		if len(out) == maxPresenceButtons {
+			break
+		}
+	}
+	return out
+}
+
+func renderButtonURL(text string, data buttonTemplateData) (string, error) {
+	tmpl, err := template.New("url").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	link := strings.TrimSpace(b.String())
+
+	if len(link) > maxButtonURLLength {
+		return "", fmt.Errorf("url is longer than %d characters", maxButtonURLLength)
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	if (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+		return "", fmt.Errorf("%q is not an http(s) URL", link)
+	}
+	return link, nil
+}
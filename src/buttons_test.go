@@ -0,0 +1,25 @@
+package main
+
+import "testing"
+
+func TestButtonValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		button PresenceButton
+		valid  bool
+	}{
+		{"plain link", PresenceButton{Label: "Portfolio", URL: "https://example.com"}, true},
+		{"file template", PresenceButton{Label: "Open", URL: "https://example.com/?q={{urlquery .File}}"}, true},
+		{"empty label", PresenceButton{Label: " ", URL: "https://example.com"}, false},
+		{"not a link", PresenceButton{Label: "Mail", URL: "mailto:me@example.com"}, false},
+		{"unknown field", PresenceButton{Label: "Open", URL: "https://example.com/{{.Page}}"}, false},
+		{"file name as the host", PresenceButton{Label: "Open", URL: "https://{{.File}}"}, false},
+		{"empty subdomain in privacy mode", PresenceButton{Label: "Open", URL: "https://{{urlquery .File}}.example.com", ShowInPrivacy: true}, true},
+		{"empty in privacy mode", PresenceButton{Label: "Open", URL: "https://{{.File}}", ShowInPrivacy: true}, false},
+	}
+	for _, tt := range tests {
+		if err := tt.button.validate(); (err == nil) != tt.valid {
+			t.Errorf("%s: validate() = %v, want valid %t", tt.name, err, tt.valid)
+		}
+	}
+}
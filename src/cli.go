@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"Figma-Discord-Rich-Presence/discordipc"
+)
+
+const cliUsage = `Usage: figma-rpc [command] [flags]
+
+Commands:
+  run [--headless]        Run the app (default). --headless skips the settings window and tray.
+  status                  Show the settings and what Figma window is detected right now.
+  config get [key]        Print one setting, or all settings as JSON. Nested keys use dots, e.g. assets.large.image.
+  config set <key> <val>  Change a setting. Restart a running figma-rpc to apply it.
+  pause <for>             Stop broadcasting for 15m, 1h (any duration), until tomorrow or until figma-closed.
+  resume                  End a pause early.
+  report [flags]          Summarize the time-tracking journal (see report --help).
+  doctor                  Check the environment for common problems.
+  version                 Print the version.
+`
+
+// runCLI dispatches os.Args to a subcommand and returns the exit code.
+// Without arguments it runs the app with its settings window, as before.
+func runCLI(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || launchedByFinder(args) {
+		return runApp(false)
+	}
+
+	switch args[0] {
+	case "run":
+		flags := flag.NewFlagSet("run", flag.ContinueOnError)
+		flags.SetOutput(stderr)
+		headless := flags.Bool("headless", false, "run without the settings window and system tray")
+		if err := flags.Parse(args[1:]); err != nil {
+			return 2
+		}
+		return runApp(*headless)
+	case "status":
+		return runStatusCommand(stdout, stderr)
+	case "config":
+		return runConfigCommand(args[1:], stdout, stderr)
+	case "pause":
+		return runPauseCommand(args[1:], stdout, stderr)
+	case "resume":
+		return runResumeCommand(stdout, stderr)
+	case "report":
+		return runReportCommand(args[1:], stdout, stderr)
+	case "doctor":
+		return runDoctorCommand(stdout)
+	case "version", "--version", "-v":
+		fmt.Fprintf(stdout, "figma-rpc v%s\n", appVersion)
+		return 0
+	case "help", "--help", "-h":
+		fmt.Fprint(stdout, cliUsage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "Unknown command %q\n\n%s", args[0], cliUsage)
+		return 2
+	}
+}
+
+// launchedByFinder reports whether macOS passed its -psn_ process serial
+// number argument, which older versions add when an app is opened from
+// Finder. Such a launch runs the app like one without arguments.
+func launchedByFinder(args []string) bool {
+	return len(args) > 0 && strings.HasPrefix(args[0], "-psn_")
+}
+
+func runStatusCommand(stdout, stderr io.Writer) int {
+	cfg, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintln(stderr, "Warning: loading config had issues:", err)
+	}
+
+	fmt.Fprintf(stdout, "figma-rpc v%s\n", appVersion)
+	fmt.Fprintf(stdout, "RPC enabled:   %t\n", cfg.RPCEnabled)
+	fmt.Fprintf(stdout, "Privacy mode:  %t\n", cfg.PrivacyMode)
+	if pause, _, err := loadPause(); err == nil && pause.Active(time.Now()) {
+		fmt.Fprintf(stdout, "Paused:        %s\n", pause.Describe())
+	}
+
+	source, err := SelectTitleSource()
+	if err != nil {
+		fmt.Fprintln(stdout, "Figma:         unknown,", err)
+		return 1
+	}
+	result, err := source.Read()
+	if err != nil {
+		fmt.Fprintf(stdout, "Figma:         unknown, %s failed: %v\n", source.Name(), err)
+		return 1
+	}
+
+	figma := parseFigmaState(result.Windows, "")
+	fmt.Fprintf(stdout, "Title source:  %s\n", source.Name())
+	switch {
+	case figma.Mode == ModeClosed:
+		fmt.Fprintln(stdout, "Figma:         not detected")
+	case figma.File != "":
+		fmt.Fprintf(stdout, "Figma:         %s %q (%d file(s) open)\n", figma.Mode, figma.File, figma.FileCount)
+	default:
+		fmt.Fprintf(stdout, "Figma:         %s\n", figma.Mode)
+	}
+	return 0
+}
+
+// runPauseCommand writes a pause that a running figma-rpc picks up within a
+// few seconds, and any later start honors until it ends.
+func runPauseCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) != 1 {
+		fmt.Fprintln(stderr, "Usage: figma-rpc pause <15m|1h|tomorrow|figma-closed>")
+		return 2
+	}
+	pause, err := parsePause(args[0], time.Now())
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	if _, err := savePause(pause); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintln(stdout, "Paused", pause.Describe())
+	return 0
+}
+
+func runResumeCommand(stdout, stderr io.Writer) int {
+	if _, err := savePause(pauseState{}); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintln(stdout, "Resumed")
+	return 0
+}
+
+func runConfigCommand(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, cliUsage)
+		return 2
+	}
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintln(stderr, "Warning: loading config had issues:", err)
+	}
+
+	switch args[0] {
+	case "get":
+		if len(args) == 1 {
+			data, err := json.MarshalIndent(cfg, "", "  ")
+			if err != nil {
+				fmt.Fprintln(stderr, "Could not serialize config:", err)
+				return 1
+			}
+			fmt.Fprintln(stdout, string(data))
+			return 0
+		}
+
+		field, ok := configField(cfg, args[1])
+		if !ok {
+			fmt.Fprintf(stderr, "Unknown setting %q. Known settings: %s\n", args[1], strings.Join(configKeys(), ", "))
+			return 2
+		}
+		data, _ := json.Marshal(field.Interface())
+		fmt.Fprintln(stdout, string(data))
+		return 0
+
+	case "set":
+		if len(args) != 3 {
+			fmt.Fprintln(stderr, "Usage: figma-rpc config set <key> <value>")
+			return 2
+		}
+
+		field, ok := configField(cfg, args[1])
+		if !ok {
+			fmt.Fprintf(stderr, "Unknown setting %q. Known settings: %s\n", args[1], strings.Join(configKeys(), ", "))
+			return 2
+		}
+		if err := setConfigValue(field, args[2]); err != nil {
+			fmt.Fprintf(stderr, "Invalid value for %s: %v\n", args[1], err)
+			return 2
+		}
+		if err := cfg.validate(); err != nil {
+			fmt.Fprintf(stderr, "Invalid value for %s: %v\n", args[1], err)
+			return 2
+		}
+		cfg.sanitize()
+		if err := cfg.Save(); err != nil {
+			fmt.Fprintln(stderr, "Error saving config:", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "%s updated. Restart figma-rpc to apply it.\n", args[1])
+		return 0
+
+	default:
+		fmt.Fprintf(stderr, "Unknown config command %q\n", args[0])
+		return 2
+	}
+}
+
+// configField finds a Config field by its JSON key. Fields of nested
+// settings are addressed with dots, e.g. "assets.large.image".
+func configField(cfg *Config, key string) (reflect.Value, bool) {
+	value := reflect.ValueOf(cfg).Elem()
+	for _, part := range strings.Split(key, ".") {
+		if value.Kind() != reflect.Struct {
+			return reflect.Value{}, false
+		}
+		found := false
+		for i := 0; i < value.NumField(); i++ {
+			field := value.Type().Field(i)
+			if field.IsExported() && jsonKey(field) == part {
+				value = value.Field(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return reflect.Value{}, false
+		}
+	}
+	return value, true
+}
+
+// configKeys lists the JSON keys accepted by `config get/set`, including
+// the dotted keys of nested settings.
+func configKeys() []string {
+	var keys []string
+	var walk func(t reflect.Type, prefix string)
+	walk = func(t reflect.Type, prefix string) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			key := prefix + jsonKey(field)
+			keys = append(keys, key)
+			if field.Type.Kind() == reflect.Struct {
+				walk(field.Type, key+".")
+			}
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+	sort.Strings(keys)
+	return keys
+}
+
+func jsonKey(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("json"), ",")[0]
+}
+
+// setConfigValue parses a command-line value into a Config field. Strings are
+// taken verbatim; everything else is parsed as JSON.
+func setConfigValue(field reflect.Value, raw string) error {
+	if field.Kind() == reflect.String {
+		field.SetString(raw)
+		return nil
+	}
+
+	target := reflect.New(field.Type())
+	if err := json.Unmarshal([]byte(raw), target.Interface()); err != nil {
+		return err
+	}
+	field.Set(target.Elem())
+	return nil
+}
+
+func runDoctorCommand(stdout io.Writer) int {
+	failures := 0
+	check := func(name string, err error, detail string) {
+		if err != nil {
+			failures++
+			fmt.Fprintf(stdout, "[FAIL] %s: %v\n", name, err)
+			return
+		}
+		fmt.Fprintf(stdout, "[ OK ] %s: %s\n", name, detail)
+	}
+
+	path, err := configPath()
+	check("Config path", err, path)
+	if err == nil {
+		_, loadErr := LoadConfig()
+		check("Config file", loadErr, "readable")
+	}
+
+	if display := os.Getenv("DISPLAY"); display != "" {
+		fmt.Fprintf(stdout, "[INFO] DISPLAY=%s\n", display)
+	}
+	if wayland := os.Getenv("WAYLAND_DISPLAY"); wayland != "" {
+		fmt.Fprintf(stdout, "[INFO] WAYLAND_DISPLAY=%s\n", wayland)
+	}
+
+	source, err := SelectTitleSource()
+	if err != nil {
+		check("Window titles", err, "")
+	} else {
+		result, readErr := source.Read()
+		check("Window titles", readErr, fmt.Sprintf("%s, %d Figma window(s) visible", source.Name(), len(result.Windows)))
+		if warning := titleSourceWarning(source); warning != "" {
+			fmt.Fprintf(stdout, "[WARN] Window titles: %s\n", warning)
+		}
+	}
+
+	socket, err := findDiscordSocket()
+	check("Discord IPC", err, socket)
+
+	idle, err := systemIdleTime()
+	check("Input idle time", err, idle.Round(time.Second).String())
+
+	if failures > 0 {
+		fmt.Fprintf(stdout, "%d check(s) failed.\n", failures)
+		return 1
+	}
+	fmt.Fprintln(stdout, "All checks passed.")
+	return 0
+}
+
+// findDiscordSocket returns the first Discord IPC socket or pipe that exists.
+func findDiscordSocket() (string, error) {
+	for _, path := range discordipc.SocketPaths() {
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", discordipc.ErrNoSocket
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLaunchedByFinder(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{nil, false},
+		{[]string{"-psn_0_1234567"}, true},
+		{[]string{"status"}, false},
+		{[]string{"run", "-psn_0_1"}, false},
+		{[]string{"-p"}, false},
+	}
+	for _, tt := range tests {
+		if got := launchedByFinder(tt.args); got != tt.want {
+			t.Errorf("launchedByFinder(%q) = %t, want %t", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestRunCLI(t *testing.T) {
+	tests := []struct {
+		args   []string
+		code   int
+		stdout string
+		stderr string
+	}{
+		{[]string{"version"}, 0, "figma-rpc v" + appVersion + "\n", ""},
+		{[]string{"--version"}, 0, "figma-rpc v" + appVersion + "\n", ""},
+		{[]string{"help"}, 0, cliUsage, ""},
+		{[]string{"frobnicate"}, 2, "", `Unknown command "frobnicate"`},
+	}
+	for _, tt := range tests {
+		var stdout, stderr bytes.Buffer
+		code := runCLI(tt.args, &stdout, &stderr)
+		if code != tt.code || stdout.String() != tt.stdout || !strings.HasPrefix(stderr.String(), tt.stderr) {
+			t.Errorf("runCLI(%q) = %d, stdout %q, stderr %q", tt.args, code, stdout.String(), stderr.String())
+		}
+	}
+}
@@ -0,0 +1,77 @@
+// Command mock-discord stands in for the Discord desktop client while
+// developing. It listens where figma-rpc looks for Discord and prints every
+// activity it receives:
+//
+//	go run ./cmd/mock-discord -dir /tmp/fake-discord
+//	XDG_RUNTIME_DIR=/tmp/fake-discord figma-rpc run --headless
+//
+// Typing "drop" on stdin disconnects all clients, like a Discord restart.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"Figma-Discord-Rich-Presence/discordipc/ipctest"
+)
+
+func main() {
+	dir := flag.String("dir", os.TempDir(), "directory to create discord-ipc-0 in")
+	flag.Parse()
+
+	if err := os.MkdirAll(*dir, 0700); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	server, err := ipctest.NewServer(*dir)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	defer server.Close()
+	fmt.Println("Listening on", server.Path())
+
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if strings.TrimSpace(scanner.Text()) == "drop" {
+				fmt.Println("Dropping all connections.")
+				server.DropConnections()
+			}
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt)
+
+	seen := 0
+	for {
+		select {
+		case <-signals:
+			return
+		case <-time.After(200 * time.Millisecond):
+		}
+
+		commands := server.Commands()
+		for _, command := range commands[seen:] {
+			activity, err := command.Activity()
+			if err != nil || command.Cmd != "SET_ACTIVITY" {
+				fmt.Printf("%s %s\n", command.Cmd, command.Args)
+				continue
+			}
+			if activity == nil {
+				fmt.Println("SET_ACTIVITY cleared")
+				continue
+			}
+			data, _ := json.Marshal(activity)
+			fmt.Printf("SET_ACTIVITY %s\n", data)
+		}
+		seen = len(commands)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"sync"
 )
 
@@ -16,7 +17,31 @@ type Config struct {
 	CustomLabel string `json:"custom_label"` // Text shown instead of file name when privacy is on
 	RPCEnabled  bool   `json:"rpc_enabled"`  // Whether Discord RPC connection is active
 	FirstRun    bool   `json:"first_run"`    // Show settings window on first launch
-	mu          sync.Mutex
+
+	ShowOtherFiles bool `json:"show_other_files"` // Append "+N other files" when several files are open
+
+	IdleTimeoutMinutes    int    `json:"idle_timeout_minutes"`    // Minutes without input before going idle, 0 disables
+	UnchangedTitleMinutes int    `json:"unchanged_title_minutes"` // Minutes on the same title before going idle, 0 disables
+	IdleAction            string `json:"idle_action"`             // "idle" shows an Idle activity, "clear" hides the presence
+
+	TimerMode      string `json:"timer_mode"`      // Elapsed timer: "file", "session" or "day"
+	JournalEnabled bool   `json:"journal_enabled"` // Record work intervals to journal.jsonl for `figma-rpc report`
+
+	ClearPolicy string `json:"clear_policy"` // With nothing to show: "clear" keeps the Discord connection, "disconnect" closes it
+
+	ClientID string         `json:"client_id"` // Discord application the presence is published as
+	Assets   PresenceAssets `json:"assets"`    // Images and hover texts, as asset keys of that application or https:// URLs
+
+	Buttons []PresenceButton `json:"buttons"` // Up to two link buttons under the activity, hidden in privacy mode
+
+	Templates        PresenceTemplates `json:"templates"`         // Go templates for the activity texts; empty keeps the built-in text
+	PrivacyTemplates PresenceTemplates `json:"privacy_templates"` // Used instead of Templates while privacy mode is on
+
+	PrivacyRules []PrivacyRule   `json:"privacy_rules"` // Per-file overrides of privacy mode, first match wins
+	Redactions   []RedactionRule `json:"redactions"`    // Parts of file names masked before they are published
+
+	FileNameCleanup FileNameCleanup `json:"file_name_cleanup"` // Noise trimmed from file names, e.g. "(Copy)" or "v2 final"
+	mu              sync.Mutex
 }
 
 // DefaultConfig returns sensible defaults for a fresh install.
@@ -26,12 +51,26 @@ func DefaultConfig() *Config {
 		CustomLabel: "Working on a project",
 		RPCEnabled:  true,
 		FirstRun:    true,
+
+		IdleTimeoutMinutes:    10,
+		UnchangedTitleMinutes: 0,
+		IdleAction:            idleActionShow,
+
+		TimerMode: timerModeFile,
+
+		ClearPolicy: clearPolicyClear,
+
+		ClientID: discordClientID,
+		Assets:   DefaultPresenceAssets(),
+
+		FileNameCleanup: DefaultFileNameCleanup(),
 	}
 }
 
 // configDir returns the OS-appropriate directory for storing config files.
 // Windows: %APPDATA%/FigmaRPC/
 // macOS:   ~/.config/figma-rpc/
+// Linux:   ~/.config/figma-rpc/
 func configDir() (string, error) {
 	switch runtime.GOOS {
 	case "windows":
@@ -89,15 +128,130 @@ func LoadConfig() (*Config, error) {
 	if err := json.Unmarshal(data, cfg); err != nil {
 		return DefaultConfig(), fmt.Errorf("could not parse config: %w", err)
 	}
+	if err := cfg.validate(); err != nil {
+		cfg.sanitize()
+		return cfg, fmt.Errorf("%w (using the default instead)", err)
+	}
+	cfg.sanitize()
 
 	return cfg, nil
 }
 
+// validate reports the first setting Discord would not accept.
+func (c *Config) validate() error {
+	if err := validateClientID(c.ClientID); err != nil {
+		return fmt.Errorf("client_id: %w", err)
+	}
+	if err := c.Assets.validate(); err != nil {
+		return err
+	}
+	if err := validateButtons(c.Buttons); err != nil {
+		return err
+	}
+	if err := c.Templates.validate(false); err != nil {
+		return fmt.Errorf("templates.%w", err)
+	}
+	if err := c.PrivacyTemplates.validate(true); err != nil {
+		return fmt.Errorf("privacy_templates.%w", err)
+	}
+	if err := validatePrivacyRules(c.PrivacyRules); err != nil {
+		return err
+	}
+	if err := validateRedactions(c.Redactions); err != nil {
+		return err
+	}
+	if err := c.FileNameCleanup.validate(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// sanitize replaces unknown enum values with their defaults, e.g. after a
+// hand-edited config file or `figma-rpc config set`.
+func (c *Config) sanitize() {
+	c.IdleAction = sanitizeIdleAction(c.IdleAction)
+	c.TimerMode = sanitizeTimerMode(c.TimerMode)
+	c.ClearPolicy = sanitizeClearPolicy(c.ClearPolicy)
+	if validateClientID(c.ClientID) != nil {
+		c.ClientID = discordClientID
+	}
+	c.Assets.sanitize()
+	c.Buttons = sanitizeButtons(c.Buttons)
+	c.Templates.sanitize(false)
+	c.PrivacyTemplates.sanitize(true)
+	c.PrivacyRules = sanitizePrivacyRules(c.PrivacyRules)
+	c.Redactions = sanitizeRedactions(c.Redactions)
+	c.FileNameCleanup.sanitize()
+	if c.IdleTimeoutMinutes < 0 {
+		c.IdleTimeoutMinutes = 0
+	}
+	if c.UnchangedTitleMinutes < 0 {
+		c.UnchangedTitleMinutes = 0
+	}
+}
+
 // Save writes the current config to disk as formatted JSON.
 func (c *Config) Save() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
+	return c.save()
+}
+
+// update applies a change and saves, both under the lock, so Clone never
+// sees half of it.
+func (c *Config) update(change func()) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	change()
+	return c.save()
+}
+
+// Clone returns a deep copy taken under the lock. The UI keeps editing its
+// Config, so the RPC manager is handed a clone of its own.
+func (c *Config) Clone() *Config {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return &Config{
+		PrivacyMode: c.PrivacyMode,
+		CustomLabel: c.CustomLabel,
+		RPCEnabled:  c.RPCEnabled,
+		FirstRun:    c.FirstRun,
+
+		ShowOtherFiles: c.ShowOtherFiles,
+
+		IdleTimeoutMinutes:    c.IdleTimeoutMinutes,
+		UnchangedTitleMinutes: c.UnchangedTitleMinutes,
+		IdleAction:            c.IdleAction,
 
+		TimerMode:      c.TimerMode,
+		JournalEnabled: c.JournalEnabled,
+
+		ClearPolicy: c.ClearPolicy,
+
+		ClientID: c.ClientID,
+		Assets:   c.Assets,
+
+		Buttons: slices.Clone(c.Buttons),
+
+		Templates:        c.Templates,
+		PrivacyTemplates: c.PrivacyTemplates,
+
+		PrivacyRules: slices.Clone(c.PrivacyRules),
+		Redactions:   slices.Clone(c.Redactions),
+
+		FileNameCleanup: FileNameCleanup{
+			Enabled:            c.FileNameCleanup.Enabled,
+			TrimSuffixes:       slices.Clone(c.FileNameCleanup.TrimSuffixes),
+			TrimPrefixes:       slices.Clone(c.FileNameCleanup.TrimPrefixes),
+			TrimLooseSuffixes:  c.FileNameCleanup.TrimLooseSuffixes,
+			StripTrailingEmoji: c.FileNameCleanup.StripTrailingEmoji,
+		},
+	}
+}
+
+// save writes the config; the caller holds c.mu.
+func (c *Config) save() error {
 	path, err := configPath()
 	if err != nil {
 		return err
@@ -123,24 +277,111 @@ func (c *Config) Save() error {
 
 // SetPrivacyMode updates the privacy mode setting and saves.
 func (c *Config) SetPrivacyMode(enabled bool) error {
-	c.PrivacyMode = enabled
-	return c.Save()
+	return c.update(func() { c.PrivacyMode = enabled })
 }
 
 // SetCustomLabel updates the custom label and saves.
 func (c *Config) SetCustomLabel(label string) error {
-	c.CustomLabel = label
-	return c.Save()
+	return c.update(func() { c.CustomLabel = label })
 }
 
 // SetRPCEnabled updates the RPC toggle and saves.
 func (c *Config) SetRPCEnabled(enabled bool) error {
-	c.RPCEnabled = enabled
-	return c.Save()
+	return c.update(func() { c.RPCEnabled = enabled })
+}
+
+// SetShowOtherFiles updates the other-files counter toggle and saves.
+func (c *Config) SetShowOtherFiles(enabled bool) error {
+	return c.update(func() { c.ShowOtherFiles = enabled })
+}
+
+// SetIdleTimeoutMinutes updates the input idle threshold and saves.
+func (c *Config) SetIdleTimeoutMinutes(minutes int) error {
+	return c.update(func() { c.IdleTimeoutMinutes = minutes })
+}
+
+// SetIdleAction updates what happens when the user goes idle and saves.
+func (c *Config) SetIdleAction(action string) error {
+	return c.update(func() { c.IdleAction = sanitizeIdleAction(action) })
+}
+
+// SetTimerMode updates how the elapsed timer is counted and saves.
+func (c *Config) SetTimerMode(mode string) error {
+	return c.update(func() { c.TimerMode = sanitizeTimerMode(mode) })
+}
+
+// SetJournalEnabled updates the time-tracking journal toggle and saves.
+func (c *Config) SetJournalEnabled(enabled bool) error {
+	return c.update(func() { c.JournalEnabled = enabled })
+}
+
+// SetClearPolicy updates how the presence is removed when there is nothing
+// to show and saves.
+func (c *Config) SetClearPolicy(policy string) error {
+	return c.update(func() { c.ClearPolicy = sanitizeClearPolicy(policy) })
+}
+
+// SetClientID updates the Discord application ID and saves. Invalid IDs are
+// rejected.
+func (c *Config) SetClientID(clientID string) error {
+	if err := validateClientID(clientID); err != nil {
+		return err
+	}
+	return c.update(func() { c.ClientID = clientID })
+}
+
+// SetAssets updates the presence images and texts and saves. Invalid values
+// are rejected.
+func (c *Config) SetAssets(assets PresenceAssets) error {
+	if err := assets.validate(); err != nil {
+		return err
+	}
+	return c.update(func() { c.Assets = assets })
+}
+
+// SetButtons updates the activity buttons and saves. Invalid buttons are
+// rejected.
+func (c *Config) SetButtons(buttons []PresenceButton) error {
+	if err := validateButtons(buttons); err != nil {
+		return err
+	}
+	return c.update(func() { c.Buttons = buttons })
+}
+
+// SetTemplates updates the activity text templates for normal and privacy
+// mode and saves. Templates that don't render are rejected.
+func (c *Config) SetTemplates(normal, privacy PresenceTemplates) error {
+	if err := normal.validate(false); err != nil {
+		return err
+	}
+	if err := privacy.validate(true); err != nil {
+		return err
+	}
+	return c.update(func() {
+		c.Templates = normal
+		c.PrivacyTemplates = privacy
+	})
+}
+
+// SetPrivacyRules updates the per-file privacy rules and saves. Invalid
+// rules are rejected.
+func (c *Config) SetPrivacyRules(rules []PrivacyRule) error {
+	if err := validatePrivacyRules(rules); err != nil {
+		return err
+	}
+	return c.update(func() { c.PrivacyRules = rules })
+}
+
+// SetRedactions updates the file name redactions and saves. Invalid
+// patterns are rejected.
+func (c *Config) SetRedactions(rules []RedactionRule) error {
+	if err := validateRedactions(rules); err != nil {
+		return err
+	}
+	return c.update(func() { c.Redactions = rules })
 }
 
 // SetFirstRun updates the first-run flag and saves.
 func (c *Config) SetFirstRun(firstRun bool) error {
-	c.FirstRun = firstRun
-	return c.Save()
+	return c.update(func() { c.FirstRun = firstRun })
 }
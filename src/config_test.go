@@ -0,0 +1,53 @@
+package main
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestConfigCloneIsIndependent(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("APPDATA", home)
+
+	cfg := DefaultConfig()
+	cfg.Buttons = []PresenceButton{{Label: "Portfolio", URL: "https://example.com"}}
+	cfg.PrivacyRules = []PrivacyRule{{Match: "Client *", Action: privacyActionHide}}
+	cfg.Redactions = []RedactionRule{{Builtin: redactBuiltinEmail}}
+
+	clone := cfg.Clone()
+	if !reflect.DeepEqual(clone.Buttons, cfg.Buttons) || !reflect.DeepEqual(clone.FileNameCleanup, cfg.FileNameCleanup) ||
+		clone.ClientID != cfg.ClientID || clone.CustomLabel != cfg.CustomLabel {
+		t.Fatalf("clone %+v differs from %+v", clone, cfg)
+	}
+
+	cfg.Buttons[0].Label = "Changed"
+	cfg.PrivacyRules[0].Action = privacyActionShow
+	cfg.Redactions[0].Builtin = redactBuiltinTicket
+	cfg.FileNameCleanup.TrimSuffixes[0] = "changed"
+	if clone.Buttons[0].Label != "Portfolio" || clone.PrivacyRules[0].Action != privacyActionHide ||
+		clone.Redactions[0].Builtin != redactBuiltinEmail || clone.FileNameCleanup.TrimSuffixes[0] == "changed" {
+		t.Errorf("editing the config changed its clone: %+v", clone)
+	}
+
+	// The UI saves settings while the RPC manager is handed clones; with
+	// -race this catches a setter writing outside the lock.
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for range 20 {
+			cfg.SetCustomLabel("Busy")
+			cfg.SetRedactions(nil)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range 20 {
+			clone := cfg.Clone()
+			_ = presenceOptionsFromConfig(clone)
+		}
+	}()
+	wg.Wait()
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"Figma-Discord-Rich-Presence/discordipc"
+)
+
+// connectResult is a finished connection, or a rejection by Discord that the
+// user should see (the attempt keeps retrying). cancel identifies the attempt
+// it came from, so a result from an abandoned attempt can be told apart.
+type connectResult struct {
+	conn   discordClient
+	err    error
+	cancel chan struct{}
+}
+
+// discordConnector dials Discord on its own goroutine, retrying with backoff,
+// so the RPC manager keeps handling UI events and Figma updates while Discord
+// is closed. All methods are called from the RPC manager goroutine.
+type discordConnector struct {
+	clientID string
+	dial     discordDialer
+	after    func(time.Duration) <-chan time.Time
+	results  chan connectResult
+	cancel   chan struct{} // nil while no attempt is running
+}
+
+func newDiscordConnector(clientID string, dial discordDialer) *discordConnector {
+	return &discordConnector{
+		clientID: clientID,
+		dial:     dial,
+		after:    time.After,
+		results:  make(chan connectResult),
+	}
+}
+
+// Start begins connecting in the background unless an attempt is running.
+func (c *discordConnector) Start() {
+	if c.cancel != nil {
+		return
+	}
+	c.cancel = make(chan struct{})
+	go c.run(c.clientID, c.cancel)
+}
+
+// Stop abandons the running attempt, if any. A connection it opens anyway is
+// closed instead of being delivered.
+func (c *discordConnector) Stop() {
+	if c.cancel == nil {
+		return
+	}
+	close(c.cancel)
+	c.cancel = nil
+}
+
+// Connecting reports whether an attempt is running.
+func (c *discordConnector) Connecting() bool {
+	return c.cancel != nil
+}
+
+// Results delivers connections opened by attempts, and rejections by Discord.
+// Pass each one to Accept.
+func (c *discordConnector) Results() <-chan connectResult {
+	return c.results
+}
+
+// Accept returns the connection of the running attempt and marks it
+// finished, or the error Discord rejected it with. Results of stopped
+// attempts are closed and yield neither.
+func (c *discordConnector) Accept(result connectResult) (discordClient, error) {
+	if c.cancel == nil || result.cancel != c.cancel {
+		if result.conn != nil {
+			result.conn.Close()
+		}
+		return nil, nil
+	}
+	if result.err != nil {
+		return nil, result.err
+	}
+	c.cancel = nil
+	return result.conn, nil
+}
+
+func (c *discordConnector) run(clientID string, cancel chan struct{}) {
+	backoff := newReconnectBackoff()
+	for {
+		conn, err := c.dial(clientID)
+		if err == nil {
+			select {
+			case c.results <- connectResult{conn: conn, cancel: cancel}:
+			case <-cancel:
+				conn.Close()
+			}
+			return
+		}
+
+		var discordErr *discordipc.Error
+		if errors.As(err, &discordErr) {
+			// Discord answered but refused, e.g. an unknown application ID.
+			fmt.Println("Discord rejected the connection:", err)
+			select {
+			case c.results <- connectResult{err: err, cancel: cancel}:
+			case <-cancel:
+				return
+			}
+		}
+
+		select {
+		case <-cancel:
+			return
+		default:
+		}
+
+		delay := backoff.Next()
+		fmt.Printf("Waiting for Discord... retrying in %s\n", delay.Round(time.Second))
+		select {
+		case <-cancel:
+			return
+		case <-c.after(delay):
+		}
+
+		// Both may be ready, and select picks at random.
+		select {
+		case <-cancel:
+			return
+		default:
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestManagerDisconnectDuringBackoffStopsDialing(t *testing.T) {
+	h := startManager(t, DefaultConfig(), "")
+	h.refuse.Store(true)
+	h.source.Replace(parseTitleScript("Logo – Figma")...)
+
+	// The first dial is refused and the connector backs off.
+	h.waitDials(1)
+	h.events.Disconnect <- struct{}{}
+	h.waitState(presenceDisabled)
+	dials := h.dials.Load()
+
+	// Discord comes up, but nothing may dial it anymore.
+	h.refuse.Store(false)
+	time.Sleep(2 * reconnectBaseDelay)
+	if n := h.dials.Load(); n != dials {
+		t.Errorf("dialed %d times, want no dial after Disconnect (%d before)", n, dials)
+	}
+	if handshakes := h.server.Handshakes(); len(handshakes) != 0 {
+		t.Errorf("handshakes = %v, want none", handshakes)
+	}
+	h.waitState(presenceDisabled)
+}
+
+func TestManagerDropsConnectionFinishingAfterDisconnect(t *testing.T) {
+	h := startManager(t, DefaultConfig(), "")
+	h.refuse.Store(true)
+	h.source.Replace(parseTitleScript("Logo – Figma")...)
+	h.waitDials(1)
+
+	// The retry after the backoff is stalled on its way to Discord...
+	h.dialMu.Lock()
+	h.refuse.Store(false)
+	h.waitDials(2)
+	h.events.Disconnect <- struct{}{}
+	h.waitState(presenceDisabled)
+
+	// ...and gets through only after Disconnect.
+	h.dialMu.Unlock()
+	deadline := time.Now().Add(3 * time.Second)
+	for len(h.server.Handshakes()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the stalled dial never reached Discord")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if commands := h.server.Commands(); len(commands) != 0 {
+		t.Errorf("late connection was used: commands %+v", commands)
+	}
+	h.waitState(presenceDisabled)
+
+	// Had the late connection been kept, Reconnect would publish over it
+	// without dialing.
+	h.events.Reconnect <- struct{}{}
+	h.activity(1)
+	if n := h.dials.Load(); n != 3 {
+		t.Errorf("dialed %d times, want a fresh dial for Reconnect", n)
+	}
+	if handshakes := h.server.Handshakes(); len(handshakes) != 2 {
+		t.Errorf("handshakes = %v, want 2", handshakes)
+	}
+}
@@ -5,6 +5,8 @@ package main
 import (
 	"fmt"
 	"os/exec"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -13,12 +15,30 @@ const accessibilityCooldown = 15 * time.Second
 
 var accessibilityRetryAfter time.Time
 
-// GetFigmaTitle uses AppleScript to find Figma window titles on macOS.
+// frontmostMarker prefixes the script output when Figma is the frontmost app.
+const frontmostMarker = "__FRONTMOST__"
+
+// appleScriptTitleSource uses AppleScript to find Figma window titles on macOS.
 // NOTE: Requires Accessibility permissions in System Settings -> Privacy & Security -> Accessibility.
-func GetFigmaTitle() (string, error) {
+type appleScriptTitleSource struct{}
+
+func init() {
+	RegisterTitleSource(0, appleScriptTitleSource{})
+}
+
+func (appleScriptTitleSource) Name() string {
+	return "AppleScript (System Events)"
+}
+
+func (appleScriptTitleSource) Probe() error {
+	_, err := exec.LookPath("osascript")
+	return err
+}
+
+func (appleScriptTitleSource) Read() (TitleResult, error) {
 	// Avoid hammering osascript if Accessibility is denied.
 	if time.Now().Before(accessibilityRetryAfter) {
-		return "", nil
+		return TitleResult{}, nil
 	}
 
 	script := `
@@ -33,6 +53,7 @@ func GetFigmaTitle() (string, error) {
             end if
 
             set figmaProcess to item 1 of figmaProcesses
+            set isFrontmost to frontmost of figmaProcess
 
             try
                 set windowNames to name of every window of figmaProcess
@@ -44,6 +65,9 @@ func GetFigmaTitle() (string, error) {
             set AppleScript's text item delimiters to linefeed
             set outputText to windowNames as text
             set AppleScript's text item delimiters to oldDelims
+            if isFrontmost then
+                set outputText to "__FRONTMOST__" & linefeed & outputText
+            end if
             return outputText
         end tell
     `
@@ -53,17 +77,17 @@ func GetFigmaTitle() (string, error) {
 		outputStr := strings.TrimSpace(string(out))
 		if isAccessibilityError(outputStr) {
 			accessibilityRetryAfter = time.Now().Add(accessibilityCooldown)
-			return "", fmt.Errorf("accessibility permissions required: grant access to figma-rpc in System Settings -> Privacy & Security -> Accessibility")
+			return TitleResult{}, fmt.Errorf("accessibility permissions required: grant access to figma-rpc in System Settings -> Privacy & Security -> Accessibility")
 		}
 		if outputStr != "" {
-			return "", fmt.Errorf("%w: %s", err, outputStr)
+			return TitleResult{}, fmt.Errorf("%w: %s", err, outputStr)
 		}
-		return "", err
+		return TitleResult{}, err
 	}
 
 	output := strings.TrimSpace(string(out))
 	if output == "" {
-		return "", nil
+		return TitleResult{}, nil
 	}
 
 	if strings.HasPrefix(output, "__ERROR__|") {
@@ -79,50 +103,22 @@ func GetFigmaTitle() (string, error) {
 
 		if isAccessibilityError(errMsg) || errNum == "1002" {
 			accessibilityRetryAfter = time.Now().Add(accessibilityCooldown)
-			return "", fmt.Errorf("accessibility permissions required: grant access to figma-rpc in System Settings -> Privacy & Security -> Accessibility")
-		}
-
-		return "", fmt.Errorf("applescript window query failed (%s): %s", errNum, errMsg)
-	}
-
-	titles := splitWindowTitles(output)
-	homeTitle := false
-	var fallbackTitle string
-
-	for _, title := range titles {
-		base, ok := trimFigmaSuffix(title)
-		if !ok {
-			lowerTitle := strings.ToLower(strings.TrimSpace(title))
-			if strings.Contains(lowerTitle, "home") || strings.Contains(lowerTitle, "drafts") {
-				homeTitle = true
-				continue
-			}
-			if fallbackTitle == "" && strings.TrimSpace(title) != "" {
-				fallbackTitle = strings.TrimSpace(title)
-			}
-			continue
-		}
-
-		lowerBase := strings.ToLower(base)
-		if lowerBase == "home" || lowerBase == "drafts" {
-			homeTitle = true
-			continue
+			return TitleResult{}, fmt.Errorf("accessibility permissions required: grant access to figma-rpc in System Settings -> Privacy & Security -> Accessibility")
 		}
 
-		if base != "" {
-			return base, nil
-		}
+		return TitleResult{}, fmt.Errorf("applescript window query failed (%s): %s", errNum, errMsg)
 	}
 
-	if homeTitle {
-		return "Browsing Files", nil
-	}
+	// Windows are listed front to back, so the first one is focused when
+	// Figma is the frontmost app.
+	frontmost := strings.HasPrefix(output, frontmostMarker)
+	output = strings.TrimPrefix(output, frontmostMarker)
 
-	if fallbackTitle != "" {
-		return fallbackTitle, nil
+	var result TitleResult
+	for i, title := range splitWindowTitles(output) {
+		result.Windows = append(result.Windows, FigmaWindow{Title: title, Focused: frontmost && i == 0})
 	}
-
-	return "", nil
+	return result, nil
 }
 
 func isAccessibilityError(output string) bool {
@@ -151,18 +147,27 @@ func splitWindowTitles(output string) []string {
 	return titles
 }
 
-func trimFigmaSuffix(title string) (string, bool) {
-	suffixes := []string{
-		" - Figma",
-		" \u2013 Figma",
-		" \u2014 Figma",
+var hidIdleTimePattern = regexp.MustCompile(`"HIDIdleTime"\s*=\s*(\d+)`)
+
+// systemIdleTime reads HIDIdleTime (nanoseconds since the last input) from IOKit.
+func systemIdleTime() (time.Duration, error) {
+	out, err := exec.Command("ioreg", "-c", "IOHIDSystem", "-d", "4").Output()
+	if err != nil {
+		return 0, fmt.Errorf("ioreg failed: %w", err)
 	}
 
-	for _, suffix := range suffixes {
-		if strings.HasSuffix(title, suffix) {
-			return strings.TrimSpace(strings.TrimSuffix(title, suffix)), true
-		}
+	match := hidIdleTimePattern.FindSubmatch(out)
+	if match == nil {
+		return 0, fmt.Errorf("HIDIdleTime not found in ioreg output")
 	}
 
-	return "", false
+	nanos, err := strconv.ParseInt(string(match[1]), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse HIDIdleTime: %w", err)
+	}
+	return time.Duration(nanos), nil
 }
+
+// attachParentConsole is only needed on Windows, where the GUI build has no
+// console.
+func attachParentConsole() {}
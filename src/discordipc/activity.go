@@ -0,0 +1,70 @@
+package discordipc
+
+import "time"
+
+// Activity is the rich presence shown on the user's Discord profile.
+type Activity struct {
+	Details    string
+	State      string
+	LargeImage string // Asset key or https:// URL
+	LargeText  string
+	SmallImage string // Asset key or https:// URL
+	SmallText  string
+	Start      time.Time // Zero hides the elapsed timer
+	Buttons    []Button  // At most two
+}
+
+// Button is a link button below the activity.
+type Button struct {
+	Label string
+	URL   string
+}
+
+type activityPayload struct {
+	Details    string             `json:"details,omitempty"`
+	State      string             `json:"state,omitempty"`
+	Assets     *assetsPayload     `json:"assets,omitempty"`
+	Timestamps *timestampsPayload `json:"timestamps,omitempty"`
+	Buttons    []buttonPayload    `json:"buttons,omitempty"`
+}
+
+type assetsPayload struct {
+	LargeImage string `json:"large_image,omitempty"`
+	LargeText  string `json:"large_text,omitempty"`
+	SmallImage string `json:"small_image,omitempty"`
+	SmallText  string `json:"small_text,omitempty"`
+}
+
+type timestampsPayload struct {
+	Start int64 `json:"start,omitempty"`
+}
+
+type buttonPayload struct {
+	Label string `json:"label"`
+	URL   string `json:"url"`
+}
+
+// payload converts an Activity to its wire form. A nil activity stays nil,
+// which Discord treats as "clear the presence".
+func (a *Activity) payload() *activityPayload {
+	if a == nil {
+		return nil
+	}
+
+	p := &activityPayload{Details: a.Details, State: a.State}
+	if a.LargeImage != "" || a.LargeText != "" || a.SmallImage != "" || a.SmallText != "" {
+		p.Assets = &assetsPayload{
+			LargeImage: a.LargeImage,
+			LargeText:  a.LargeText,
+			SmallImage: a.SmallImage,
+			SmallText:  a.SmallText,
+		}
+	}
+	if !a.Start.IsZero() {
+		p.Timestamps = &timestampsPayload{Start: a.Start.UnixMilli()}
+	}
+	for _, button := range a.Buttons {
+		p.Buttons = append(p.Buttons, buttonPayload{Label: button.Label, URL: button.URL})
+	}
+	return p
+}
@@ -0,0 +1,278 @@
+// Package discordipc is a small client for Discord's local RPC socket. It does
+// the handshake and framing itself and only supports what rich presence needs.
+package discordipc
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrNoSocket is returned by Dial when no Discord IPC socket accepts a connection.
+var ErrNoSocket = errors.New("no discord ipc socket found (is Discord running?)")
+
+// ioTimeout bounds every request so a stuck Discord client cannot hang the caller.
+const ioTimeout = 5 * time.Second
+
+// Error is an error reported by Discord, either as an ERROR event or as a
+// CLOSE frame (for example code 4000 for an invalid client ID).
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+
+	closed bool // Sent as a CLOSE frame; the connection is gone
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("discord rpc error %d: %s", e.Code, e.Message)
+}
+
+// User is the Discord account the client is logged in as.
+type User struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// Client is one open connection to the Discord desktop client. It is safe
+// for concurrent use.
+type Client struct {
+	mu   sync.Mutex
+	conn net.Conn
+	user User
+	path string
+}
+
+type handshakePayload struct {
+	V        int    `json:"v"`
+	ClientID string `json:"client_id"`
+}
+
+type commandPayload struct {
+	Cmd   string `json:"cmd"`
+	Args  any    `json:"args"`
+	Nonce string `json:"nonce"`
+}
+
+type setActivityArgs struct {
+	PID      int              `json:"pid"`
+	Activity *activityPayload `json:"activity"`
+}
+
+type responsePayload struct {
+	Cmd   string          `json:"cmd"`
+	Evt   string          `json:"evt"`
+	Nonce string          `json:"nonce"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Dial connects to the first Discord IPC socket that answers (discord-ipc-0
+// through discord-ipc-9 in every known location) and performs the handshake
+// for the given application ID.
+func Dial(clientID string) (*Client, error) {
+	var lastErr error
+	for _, path := range SocketPaths() {
+		conn, err := dialSocket(path)
+		if err != nil {
+			continue
+		}
+
+		client := &Client{conn: conn, path: path}
+		if err := client.handshake(clientID); err != nil {
+			conn.Close()
+			lastErr = err
+			var discordErr *Error
+			if errors.As(err, &discordErr) {
+				// Discord itself rejected us; other sockets will say the same.
+				return nil, err
+			}
+			continue
+		}
+		return client, nil
+	}
+
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, ErrNoSocket
+}
+
+// NewClient performs the handshake over an already open connection. It is
+// mainly useful for custom transports and tests.
+func NewClient(conn net.Conn, clientID string) (*Client, error) {
+	client := &Client{conn: conn, path: conn.RemoteAddr().String()}
+	if err := client.handshake(clientID); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return client, nil
+}
+
+func (c *Client) handshake(clientID string) error {
+	payload, err := json.Marshal(handshakePayload{V: 1, ClientID: clientID})
+	if err != nil {
+		return err
+	}
+
+	c.conn.SetDeadline(time.Now().Add(ioTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	if err := WriteFrame(c.conn, OpHandshake, payload); err != nil {
+		return fmt.Errorf("discord handshake failed: %w", err)
+	}
+
+	response, err := c.readResponse("")
+	if err != nil {
+		return fmt.Errorf("discord handshake failed: %w", err)
+	}
+	if response.Evt != "READY" {
+		return fmt.Errorf("discord handshake failed: unexpected %s event", response.Evt)
+	}
+
+	var ready struct {
+		User User `json:"user"`
+	}
+	if err := json.Unmarshal(response.Data, &ready); err == nil {
+		c.user = ready.User
+	}
+	return nil
+}
+
+// User returns the account from the READY event.
+func (c *Client) User() User {
+	return c.user
+}
+
+// Path returns the socket or pipe the client is connected to.
+func (c *Client) Path() string {
+	return c.path
+}
+
+// SetActivity publishes an activity. A nil activity clears the presence
+// while keeping the connection open.
+func (c *Client) SetActivity(activity *Activity) error {
+	args := setActivityArgs{PID: os.Getpid(), Activity: activity.payload()}
+	_, err := c.command("SET_ACTIVITY", args)
+	return err
+}
+
+// Close ends the connection.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return nil
+	}
+	// A Discord client that stopped reading must not hang shutdown.
+	c.conn.SetWriteDeadline(time.Now().Add(ioTimeout))
+	WriteFrame(c.conn, OpClose, []byte("{}"))
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+func (c *Client) command(cmd string, args any) (responsePayload, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn == nil {
+		return responsePayload{}, net.ErrClosed
+	}
+
+	nonce := newNonce()
+	payload, err := json.Marshal(commandPayload{Cmd: cmd, Args: args, Nonce: nonce})
+	if err != nil {
+		return responsePayload{}, err
+	}
+
+	c.conn.SetDeadline(time.Now().Add(ioTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	if err := WriteFrame(c.conn, OpFrame, payload); err != nil {
+		return responsePayload{}, err
+	}
+	return c.readResponse(nonce)
+}
+
+// readResponse reads frames until the reply to nonce arrives (or any
+// DISPATCH when nonce is empty), answering pings along the way.
+func (c *Client) readResponse(nonce string) (responsePayload, error) {
+	for {
+		opcode, data, err := ReadFrame(c.conn)
+		if err != nil {
+			return responsePayload{}, err
+		}
+
+		switch opcode {
+		case OpPing:
+			if err := WriteFrame(c.conn, OpPong, data); err != nil {
+				return responsePayload{}, err
+			}
+			continue
+		case OpClose:
+			closeErr := &Error{closed: true}
+			if err := json.Unmarshal(data, closeErr); err != nil || closeErr.Message == "" {
+				closeErr.Message = "connection closed by discord"
+			}
+			return responsePayload{}, closeErr
+		case OpFrame:
+		default:
+			continue
+		}
+
+		var response responsePayload
+		if err := json.Unmarshal(data, &response); err != nil {
+			return responsePayload{}, fmt.Errorf("invalid discord response: %w", err)
+		}
+		if nonce != "" && response.Nonce != nonce {
+			continue
+		}
+		if response.Evt == "ERROR" {
+			discordErr := &Error{}
+			if err := json.Unmarshal(response.Data, discordErr); err != nil {
+				discordErr.Message = string(response.Data)
+			}
+			return response, discordErr
+		}
+		return response, nil
+	}
+}
+
+func newNonce() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	buf[6] = (buf[6] & 0x0f) | 0x40
+	buf[8] = (buf[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", buf[0:4], buf[4:6], buf[6:8], buf[8:10], buf[10:])
+}
+
+// IsConnectionError reports whether err means the connection to Discord is
+// gone (Discord quit or restarted), as opposed to Discord rejecting a request.
+// A connection error requires dialing again.
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var discordErr *Error
+	if errors.As(err, &discordErr) {
+		return discordErr.closed
+	}
+
+	return errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, io.ErrClosedPipe) ||
+		errors.Is(err, net.ErrClosed) ||
+		errors.Is(err, os.ErrDeadlineExceeded) ||
+		errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNABORTED) ||
+		isPipeClosed(err)
+}
@@ -0,0 +1,150 @@
+package discordipc_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"Figma-Discord-Rich-Presence/discordipc"
+	"Figma-Discord-Rich-Presence/discordipc/ipctest"
+)
+
+func newServer(t *testing.T) *ipctest.Server {
+	t.Helper()
+	server, err := ipctest.NewServer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+func TestClientSetsActivity(t *testing.T) {
+	server := newServer(t)
+	client, err := server.Dial("42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	if client.User().Username != "ipctest" {
+		t.Errorf("user = %+v, want the one from READY", client.User())
+	}
+	if err := client.SetActivity(&discordipc.Activity{Details: "Logo", State: "Editing"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetActivity(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	commands, err := server.WaitForCommands(2, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	activity, err := commands[0].Activity()
+	if err != nil || commands[0].Cmd != "SET_ACTIVITY" || activity["details"] != "Logo" {
+		t.Errorf("first command = %s %v, %v", commands[0].Cmd, activity, err)
+	}
+	if cleared, err := commands[1].Activity(); err != nil || cleared != nil {
+		t.Errorf("clearing sent %v, %v", cleared, err)
+	}
+}
+
+func TestClientHandshakeRejected(t *testing.T) {
+	server := newServer(t)
+	server.RejectNextHandshake(4000, "Invalid Client ID")
+
+	_, err := server.Dial("not-an-app")
+	var discordErr *discordipc.Error
+	if !errors.As(err, &discordErr) || discordErr.Code != 4000 {
+		t.Fatalf("dial error = %v, want a discord error with code 4000", err)
+	}
+	if !discordipc.IsConnectionError(err) {
+		t.Errorf("a rejected handshake closes the connection, IsConnectionError(%v) = false", err)
+	}
+	if got := server.Handshakes(); len(got) != 0 {
+		t.Errorf("handshakes = %v, want none accepted", got)
+	}
+}
+
+func TestClientCommandError(t *testing.T) {
+	server := newServer(t)
+	client, err := server.Dial("42")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	server.FailNextCommand(4002, "child \"activity\" fails")
+	err = client.SetActivity(&discordipc.Activity{Details: "Logo"})
+	var discordErr *discordipc.Error
+	if !errors.As(err, &discordErr) || discordErr.Code != 4002 {
+		t.Fatalf("SET_ACTIVITY error = %v, want a discord error with code 4002", err)
+	}
+	if discordipc.IsConnectionError(err) {
+		t.Errorf("a rejected activity keeps the connection, IsConnectionError(%v) = true", err)
+	}
+
+	// The connection is still usable.
+	if err := client.SetActivity(&discordipc.Activity{Details: "Logo"}); err != nil {
+		t.Errorf("SET_ACTIVITY after an error: %v", err)
+	}
+}
+
+func TestIsConnectionError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{nil, false},
+		{io.EOF, true},
+		{fmt.Errorf("discord handshake failed: %w", io.ErrUnexpectedEOF), true},
+		{&net.OpError{Op: "write", Net: "unix", Err: &os.SyscallError{Syscall: "write", Err: syscall.EPIPE}}, true},
+		{net.ErrClosed, true},
+		{os.ErrDeadlineExceeded, true},
+		{&discordipc.Error{Code: 4002, Message: "invalid activity"}, false},
+		{errors.New("invalid discord response"), false},
+	}
+	for _, tt := range tests {
+		if got := discordipc.IsConnectionError(tt.err); got != tt.want {
+			t.Errorf("IsConnectionError(%v) = %t, want %t", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestClientCloseDoesNotHang(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits for the write deadline")
+	}
+
+	conn, peer := net.Pipe()
+	defer peer.Close()
+	go func() {
+		discordipc.ReadFrame(peer)
+		ready, _ := json.Marshal(map[string]any{"cmd": "DISPATCH", "evt": "READY", "data": map[string]any{}})
+		discordipc.WriteFrame(peer, discordipc.OpFrame, ready)
+		// Then stop reading, like a Discord client that hung.
+	}()
+
+	client, err := discordipc.NewClient(conn, "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		client.Close()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Close blocked on a peer that does not read")
+	}
+}
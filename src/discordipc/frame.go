@@ -0,0 +1,50 @@
+package discordipc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Opcodes of the Discord IPC framing.
+const (
+	OpHandshake uint32 = 0
+	OpFrame     uint32 = 1
+	OpClose     uint32 = 2
+	OpPing      uint32 = 3
+	OpPong      uint32 = 4
+)
+
+// maxFrameSize guards against reading garbage as a huge length.
+const maxFrameSize = 1 << 20
+
+// WriteFrame writes one frame: a little-endian opcode and length, then the JSON payload.
+func WriteFrame(w io.Writer, opcode uint32, payload []byte) error {
+	buf := make([]byte, 8+len(payload))
+	binary.LittleEndian.PutUint32(buf[0:4], opcode)
+	binary.LittleEndian.PutUint32(buf[4:8], uint32(len(payload)))
+	copy(buf[8:], payload)
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// ReadFrame reads one frame and returns its opcode and payload.
+func ReadFrame(r io.Reader) (uint32, []byte, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	opcode := binary.LittleEndian.Uint32(header[0:4])
+	length := binary.LittleEndian.Uint32(header[4:8])
+	if length > maxFrameSize {
+		return 0, nil, fmt.Errorf("discord ipc frame too large (%d bytes)", length)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+	return opcode, payload, nil
+}
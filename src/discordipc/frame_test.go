@@ -0,0 +1,68 @@
+package discordipc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	frames := []struct {
+		opcode  uint32
+		payload string
+	}{
+		{OpHandshake, `{"v":1,"client_id":"42"}`},
+		{OpFrame, `{"cmd":"SET_ACTIVITY"}`},
+		{OpPing, ""},
+	}
+	for _, frame := range frames {
+		if err := WriteFrame(&buf, frame.opcode, []byte(frame.payload)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, frame := range frames {
+		opcode, payload, err := ReadFrame(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opcode != frame.opcode || string(payload) != frame.payload {
+			t.Errorf("read %d %q, want %d %q", opcode, payload, frame.opcode, frame.payload)
+		}
+	}
+	if _, _, err := ReadFrame(&buf); !errors.Is(err, io.EOF) {
+		t.Errorf("read past the last frame: %v, want EOF", err)
+	}
+}
+
+func TestReadFrameRejectsBadFrames(t *testing.T) {
+	header := func(opcode, length uint32) []byte {
+		buf := make([]byte, 8)
+		binary.LittleEndian.PutUint32(buf[0:4], opcode)
+		binary.LittleEndian.PutUint32(buf[4:8], length)
+		return buf
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"short header", []byte{1, 0, 0}, io.ErrUnexpectedEOF},
+		{"short payload", append(header(OpFrame, 10), `{"cmd"`...), io.ErrUnexpectedEOF},
+		{"oversized", header(OpFrame, maxFrameSize+1), nil},
+	}
+	for _, tt := range tests {
+		_, _, err := ReadFrame(bytes.NewReader(tt.data))
+		if err == nil {
+			t.Errorf("%s: no error", tt.name)
+			continue
+		}
+		if tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: error %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+// Package ipctest is a fake Discord desktop client: it serves the IPC
+// protocol on a Unix socket and records what clients send, so the RPC path
+// can be exercised without Discord.
+package ipctest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"Figma-Discord-Rich-Presence/discordipc"
+)
+
+// Command is one command frame received from a client.
+type Command struct {
+	ClientID string
+	Cmd      string
+	Nonce    string
+	Args     json.RawMessage
+	Received time.Time
+}
+
+// Activity returns the activity of a SET_ACTIVITY command. It is nil when the
+// client cleared its presence.
+func (c Command) Activity() (map[string]any, error) {
+	var args struct {
+		Activity map[string]any `json:"activity"`
+	}
+	if err := json.Unmarshal(c.Args, &args); err != nil {
+		return nil, err
+	}
+	return args.Activity, nil
+}
+
+// Server accepts any number of clients on one socket. Every method is safe
+// for concurrent use.
+type Server struct {
+	listener net.Listener
+	path     string
+	user     discordipc.User
+
+	mu          sync.Mutex
+	conns       map[net.Conn]struct{}
+	handshakes  []string
+	commands    []Command
+	rejectNext  *discordipc.Error
+	failNext    *discordipc.Error
+	changed     chan struct{} // Closed and replaced on every recorded event
+	closed      bool
+	connections sync.WaitGroup
+}
+
+// NewServer listens on dir/discord-ipc-0, where discordipc.Dial finds it when
+// XDG_RUNTIME_DIR (or TMPDIR) points at dir.
+func NewServer(dir string) (*Server, error) {
+	path := filepath.Join(dir, "discord-ipc-0")
+	os.Remove(path)
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("could not listen on %s: %w", path, err)
+	}
+
+	s := &Server{
+		listener: listener,
+		path:     path,
+		user:     discordipc.User{ID: "1", Username: "ipctest"},
+		conns:    make(map[net.Conn]struct{}),
+		changed:  make(chan struct{}),
+	}
+	go s.acceptLoop()
+	return s, nil
+}
+
+// Path returns the socket path.
+func (s *Server) Path() string {
+	return s.path
+}
+
+// Dial connects a discordipc.Client to this server directly, without
+// searching the usual socket locations.
+func (s *Server) Dial(clientID string) (*discordipc.Client, error) {
+	conn, err := net.DialTimeout("unix", s.path, 2*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	return discordipc.NewClient(conn, clientID)
+}
+
+// Handshakes returns the client IDs of every accepted handshake, in order.
+func (s *Server) Handshakes() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.handshakes...)
+}
+
+// Connections returns how many clients are connected right now.
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.conns)
+}
+
+// Commands returns every command received so far, in order.
+func (s *Server) Commands() []Command {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Command(nil), s.commands...)
+}
+
+// WaitForCommands blocks until at least n commands were received and returns
+// them, or fails after timeout.
+func (s *Server) WaitForCommands(n int, timeout time.Duration) ([]Command, error) {
+	deadline := time.After(timeout)
+	for {
+		s.mu.Lock()
+		if len(s.commands) >= n {
+			commands := append([]Command(nil), s.commands...)
+			s.mu.Unlock()
+			return commands, nil
+		}
+		changed := s.changed
+		got := len(s.commands)
+		s.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return nil, fmt.Errorf("timed out waiting for %d commands, got %d", n, got)
+		}
+	}
+}
+
+// RejectNextHandshake makes the next handshake fail with a CLOSE frame, the
+// way Discord answers an unknown client ID (code 4000).
+func (s *Server) RejectNextHandshake(code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejectNext = &discordipc.Error{Code: code, Message: message}
+}
+
+// FailNextCommand makes the next command fail with an ERROR event, the way
+// Discord answers an invalid activity. The command is still recorded.
+func (s *Server) FailNextCommand(code int, message string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failNext = &discordipc.Error{Code: code, Message: message}
+}
+
+// DropConnections closes every open client connection without a CLOSE frame,
+// as if Discord crashed. The server keeps listening, like a restarted Discord.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+// Close stops listening, drops all clients and removes the socket.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	err := s.listener.Close()
+	s.DropConnections()
+	s.connections.Wait()
+	os.Remove(s.path)
+	return err
+}
+
+func (s *Server) acceptLoop() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.connections.Add(1)
+		s.mu.Unlock()
+
+		go s.serve(conn)
+	}
+}
+
+func (s *Server) serve(conn net.Conn) {
+	defer s.connections.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	clientID, err := s.handshake(conn)
+	if err != nil {
+		return
+	}
+
+	for {
+		opcode, data, err := discordipc.ReadFrame(conn)
+		if err != nil {
+			return
+		}
+
+		switch opcode {
+		case discordipc.OpClose:
+			return
+		case discordipc.OpPing:
+			if discordipc.WriteFrame(conn, discordipc.OpPong, data) != nil {
+				return
+			}
+			continue
+		case discordipc.OpFrame:
+		default:
+			continue
+		}
+
+		var command struct {
+			Cmd   string          `json:"cmd"`
+			Args  json.RawMessage `json:"args"`
+			Nonce string          `json:"nonce"`
+		}
+		if err := json.Unmarshal(data, &command); err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.commands = append(s.commands, Command{
+			ClientID: clientID,
+			Cmd:      command.Cmd,
+			Nonce:    command.Nonce,
+			Args:     command.Args,
+			Received: time.Now(),
+		})
+		failure := s.failNext
+		s.failNext = nil
+		s.notifyLocked()
+		s.mu.Unlock()
+
+		reply := map[string]any{"cmd": command.Cmd, "nonce": command.Nonce, "evt": nil}
+		if failure != nil {
+			reply["evt"] = "ERROR"
+			reply["data"] = failure
+		} else if command.Cmd == "SET_ACTIVITY" {
+			var args struct {
+				Activity json.RawMessage `json:"activity"`
+			}
+			json.Unmarshal(command.Args, &args)
+			reply["data"] = args.Activity
+		}
+		if writeJSON(conn, discordipc.OpFrame, reply) != nil {
+			return
+		}
+	}
+}
+
+// handshake answers the opening frame with READY, or with a CLOSE frame when
+// a rejection was requested.
+func (s *Server) handshake(conn net.Conn) (string, error) {
+	opcode, data, err := discordipc.ReadFrame(conn)
+	if err != nil {
+		return "", err
+	}
+	if opcode != discordipc.OpHandshake {
+		return "", errors.New("expected handshake")
+	}
+
+	var hello struct {
+		V        int    `json:"v"`
+		ClientID string `json:"client_id"`
+	}
+	if err := json.Unmarshal(data, &hello); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	reject := s.rejectNext
+	s.rejectNext = nil
+	if reject == nil {
+		s.handshakes = append(s.handshakes, hello.ClientID)
+		s.notifyLocked()
+	}
+	s.mu.Unlock()
+
+	if reject != nil {
+		writeJSON(conn, discordipc.OpClose, reject)
+		return "", reject
+	}
+
+	ready := map[string]any{
+		"cmd":  "DISPATCH",
+		"evt":  "READY",
+		"data": map[string]any{"v": 1, "user": s.user},
+	}
+	return hello.ClientID, writeJSON(conn, discordipc.OpFrame, ready)
+}
+
+func (s *Server) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+func writeJSON(conn net.Conn, opcode uint32, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return discordipc.WriteFrame(conn, opcode, data)
+}
@@ -0,0 +1,40 @@
+//go:build windows
+
+package discordipc
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"time"
+
+	"gopkg.in/natefinch/npipe.v2"
+)
+
+// SocketPaths lists the named pipes Discord may listen on.
+func SocketPaths() []string {
+	paths := make([]string, 0, 10)
+	for i := 0; i < 10; i++ {
+		paths = append(paths, fmt.Sprintf(`\\.\pipe\discord-ipc-%d`, i))
+	}
+	return paths
+}
+
+func dialSocket(path string) (net.Conn, error) {
+	return npipe.DialTimeout(path, 2*time.Second)
+}
+
+// isPipeClosed recognizes the Windows errors for a named pipe whose server
+// went away, which do not map onto the portable errors.
+func isPipeClosed(err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) {
+		return false
+	}
+	switch errno {
+	case 109, 232, 233: // ERROR_BROKEN_PIPE, ERROR_NO_DATA, ERROR_PIPE_NOT_CONNECTED
+		return true
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+const idlePollInterval = 5 * time.Second
+
+const (
+	idleActionShow  = "idle"  // Switch to an "Idle" activity
+	idleActionClear = "clear" // Clear the presence until activity resumes
+)
+
+// runIdleMonitor reports the system input-idle time every idlePollInterval.
+// It keeps ticking with a zero duration when the platform cannot report idle
+// time, so the RPC manager can still evaluate the unchanged-title rule.
+func runIdleMonitor(idleUpdates chan time.Duration, stop <-chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	lastErr := ""
+	for {
+		idle, err := systemIdleTime()
+		if err != nil {
+			if err.Error() != lastErr {
+				fmt.Println("Input idle time unavailable:", err)
+				lastErr = err.Error()
+			}
+			idle = 0
+		} else {
+			lastErr = ""
+		}
+
+		pushLatestDuration(idleUpdates, idle)
+
+		if !sleepWithStop(idlePollInterval, stop) {
+			return
+		}
+	}
+}
+
+// idleDetector decides whether the user is away, either because there has been
+// no keyboard/mouse input or because the Figma title has not changed for a while.
+// A zero threshold disables that rule.
+type idleDetector struct {
+	inputThreshold  time.Duration
+	titleThreshold  time.Duration
+	inputIdle       time.Duration
+	lastTitleChange time.Time
+}
+
+// evaluate returns whether the user is idle at now and since when.
+func (d *idleDetector) evaluate(now time.Time) (bool, time.Time) {
+	idle := false
+	since := now
+
+	if d.inputThreshold > 0 && d.inputIdle >= d.inputThreshold {
+		idle = true
+		since = now.Add(-d.inputIdle)
+	}
+
+	if d.titleThreshold > 0 && !d.lastTitleChange.IsZero() && now.Sub(d.lastTitleChange) >= d.titleThreshold {
+		idle = true
+		if d.lastTitleChange.Before(since) {
+			since = d.lastTitleChange
+		}
+	}
+
+	return idle, since
+}
+
+func sanitizeIdleAction(action string) string {
+	if action == idleActionClear {
+		return idleActionClear
+	}
+	return idleActionShow
+}
+
+func pushLatestDuration(ch chan time.Duration, value time.Duration) {
+	select {
+	case ch <- value:
+	default:
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- value
+	}
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// journalEntry is one line of journal.jsonl: a continuous interval spent in
+// one file or view.
+type journalEntry struct {
+	File   string    `json:"file"`
+	Mode   string    `json:"mode"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Hashed bool      `json:"hashed,omitempty"` // File was hashed because Privacy Mode or a privacy rule hid it
+}
+
+// journalCheckpointInterval is how often the running interval is written
+// out, so a crash loses at most this much and `figma-rpc report` includes
+// the session in progress.
+const journalCheckpointInterval = time.Minute
+
+// workJournal appends finished intervals to an append-only JSONL file next to
+// config.json. Only the RPC manager goroutine touches it.
+type workJournal struct {
+	path    string
+	now     func() time.Time
+	current *journalEntry
+}
+
+// journalPath returns the full path to the journal file.
+func journalPath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "journal.jsonl"), nil
+}
+
+func newWorkJournal() (*workJournal, error) {
+	path, err := journalPath()
+	if err != nil {
+		return nil, err
+	}
+	return &workJournal{path: path, now: time.Now}, nil
+}
+
+// Observe records the current Figma state. privacy is the decision the
+// presence uses for the file; names it hides from Discord are stored hashed.
+// A change closes the running interval and writes it out; ModeClosed (also
+// used while idle) just closes it.
+func (j *workJournal) Observe(figma FigmaState, privacy privacyDecision) {
+	file, hashed := journalFileName(figma, privacy)
+	mode := figma.Mode.String()
+	if j.current != nil && j.current.File == file && j.current.Mode == mode {
+		return
+	}
+
+	now := j.now()
+	if j.current != nil {
+		j.current.End = now
+		if err := j.append(*j.current); err != nil {
+			fmt.Println("Error writing journal:", err)
+		}
+		j.current = nil
+	}
+
+	if figma.Mode == ModeClosed {
+		return
+	}
+	j.current = &journalEntry{File: file, Mode: mode, Start: now, Hashed: hashed}
+}
+
+// Checkpoint writes the running interval up to now once it is
+// journalCheckpointInterval long, and carries on with a new one from now.
+// Reports add the pieces back up.
+func (j *workJournal) Checkpoint() {
+	if j.current == nil {
+		return
+	}
+	now := j.now()
+	if now.Sub(j.current.Start) < journalCheckpointInterval {
+		return
+	}
+
+	entry := *j.current
+	entry.End = now
+	if err := j.append(entry); err != nil {
+		fmt.Println("Error writing journal:", err)
+		return
+	}
+	j.current.Start = now
+}
+
+// Close writes the running interval, if any.
+func (j *workJournal) Close() {
+	j.Observe(FigmaState{}, privacyDecision{})
+}
+
+func journalFileName(figma FigmaState, privacy privacyDecision) (string, bool) {
+	if figma.File == "" || !privacy.HidesFile() {
+		return figma.File, false
+	}
+	return hashFileName(figma.File), true
+}
+
+func (j *workJournal) append(entry journalEntry) error {
+	if !entry.End.After(entry.Start) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return fmt.Errorf("could not create journal directory: %w", err)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("could not serialize journal entry: %w", err)
+	}
+
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("could not open journal: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("could not append to journal: %w", err)
+	}
+	return nil
+}
+
+// hashFileName replaces a file name with a short stable digest, so totals
+// still group per file without revealing the name.
+func hashFileName(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+// readJournal loads every entry from the journal file. Malformed lines, such
+// as a half-written last line, are skipped.
+func readJournal(path string) ([]journalEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not open journal: %w", err)
+	}
+	defer f.Close()
+
+	var entries []journalEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("could not read journal: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestJournalHashesFilesThePresenceHides(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PrivacyRules = []PrivacyRule{
+		{Match: "Acme *", Action: privacyActionHide},
+		{Match: "*NDA*", Action: privacyActionCustomLabel, Label: "Client work"},
+		{Match: "Logo", Action: privacyActionShow},
+	}
+
+	tests := []struct {
+		name        string
+		file        string
+		privacyMode bool
+		hashed      bool
+	}{
+		{"shown", "Brand", false, false},
+		{"hidden by a hide rule", "Acme Rebrand", false, true},
+		{"hidden by a label rule", "Partner NDA", false, true},
+		{"hidden by Privacy Mode", "Brand", true, true},
+		{"shown by a rule in Privacy Mode", "Logo", true, false},
+	}
+	for _, tt := range tests {
+		clock := newFakeClock()
+		journal := &workJournal{path: filepath.Join(t.TempDir(), "journal.jsonl"), now: clock.Now}
+		cfg.PrivacyMode = tt.privacyMode
+		options := presenceOptionsFromConfig(cfg)
+
+		figma := FigmaState{Mode: ModeEditing, File: tt.file}
+		journal.Observe(figma, options.privacyFor(figma.File))
+		clock.Advance(time.Minute)
+		journal.Close()
+
+		entries, err := readJournal(journal.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 1 {
+			t.Fatalf("%s: %d entries, want 1", tt.name, len(entries))
+		}
+		want := tt.file
+		if tt.hashed {
+			want = hashFileName(tt.file)
+		}
+		if got := entries[0]; got.File != want || got.Hashed != tt.hashed {
+			t.Errorf("%s: entry %q hashed %t, want %q hashed %t", tt.name, got.File, got.Hashed, want, tt.hashed)
+		}
+	}
+}
+
+func TestJournalSplitsWhenPrivacyChanges(t *testing.T) {
+	clock := newFakeClock()
+	journal := &workJournal{path: filepath.Join(t.TempDir(), "journal.jsonl"), now: clock.Now}
+	figma := FigmaState{Mode: ModeEditing, File: "Brand"}
+
+	journal.Observe(figma, privacyDecision{Action: privacyActionShow})
+	clock.Advance(time.Minute)
+	journal.Observe(figma, privacyDecision{Action: privacyActionLabel})
+	clock.Advance(time.Minute)
+	journal.Close()
+
+	entries, err := readJournal(journal.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Hashed || !entries[1].Hashed {
+		t.Errorf("entries = %+v, want a plain interval then a hashed one", entries)
+	}
+}
+
+func TestRecordJournalUsesCleanedUpName(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.PrivacyRules = []PrivacyRule{{Match: "Acme", Action: privacyActionLabel}}
+	clock := newFakeClock()
+	state := &rpcManagerState{
+		options: presenceOptionsFromConfig(cfg),
+		journal: &workJournal{path: filepath.Join(t.TempDir(), "journal.jsonl"), now: clock.Now},
+	}
+
+	// The first two clean up to the same name, and the last matches the
+	// rule only after cleanup.
+	for _, file := range []string{"Landing (Copy)", "Landing v2", "Acme v3"} {
+		state.currentState = FigmaState{Mode: ModeEditing, File: file}
+		recordJournal(state)
+		clock.Advance(time.Minute)
+	}
+	state.journal.Close()
+
+	entries, err := readJournal(state.journal.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []journalEntry{{File: "Landing"}, {File: hashFileName("Acme"), Hashed: true}}
+	if len(entries) != len(want) {
+		t.Fatalf("entries = %+v, want %+v", entries, want)
+	}
+	for i := range want {
+		if entries[i].File != want[i].File || entries[i].Hashed != want[i].Hashed {
+			t.Errorf("entry %d = %q hashed %t, want %q hashed %t", i, entries[i].File, entries[i].Hashed, want[i].File, want[i].Hashed)
+		}
+	}
+}
+
+func TestJournalCheckpointsTheRunningInterval(t *testing.T) {
+	clock := newFakeClock()
+	journal := &workJournal{path: filepath.Join(t.TempDir(), "journal.jsonl"), now: clock.Now}
+	start := clock.Now()
+	journal.Observe(FigmaState{Mode: ModeEditing, File: "Logo"}, privacyDecision{Action: privacyActionShow})
+
+	clock.Advance(30 * time.Second)
+	journal.Checkpoint()
+	if entries, _ := readJournal(journal.path); len(entries) != 0 {
+		t.Fatalf("checkpointed after 30s: %+v", entries)
+	}
+
+	clock.Advance(time.Minute)
+	journal.Checkpoint()
+	checkpoint := clock.Now()
+
+	// The process dies here; the first 90s are on disk.
+	entries, err := readJournal(journal.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].File != "Logo" || !entries[0].Start.Equal(start) || !entries[0].End.Equal(checkpoint) {
+		t.Fatalf("entries after the checkpoint = %+v", entries)
+	}
+
+	// Otherwise the interval carries on from the checkpoint.
+	clock.Advance(20 * time.Second)
+	journal.Close()
+	entries, err = readJournal(journal.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || !entries[1].Start.Equal(checkpoint) || !entries[1].End.Equal(clock.Now()) {
+		t.Errorf("entries after Close = %+v", entries)
+	}
+}
@@ -0,0 +1,237 @@
+//go:build linux
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/screensaver"
+	"github.com/jezek/xgb/xproto"
+)
+
+// x11TitleSource reads titles from the X server in $DISPLAY. It is also used
+// for XWayland windows when the compositor source is unavailable.
+type x11TitleSource struct{}
+
+// x11Conn is reused between polls and dropped whenever a request fails,
+// so a restarted X server (or Xvfb in tests) is picked up on the next poll.
+var x11Conn *xgb.Conn
+
+// Wayland sessions try the compositor first, since X11 only sees XWayland
+// windows there.
+func init() {
+	x11Priority, waylandPriority := 0, 10
+	if os.Getenv("WAYLAND_DISPLAY") != "" || os.Getenv("XDG_SESSION_TYPE") == "wayland" {
+		x11Priority, waylandPriority = 10, 0
+	}
+	RegisterTitleSource(x11Priority, x11TitleSource{})
+	RegisterTitleSource(waylandPriority, gnomeShellTitleSource{})
+}
+
+func (x11TitleSource) Name() string {
+	return "X11 (_NET_CLIENT_LIST)"
+}
+
+func (s x11TitleSource) Probe() error {
+	_, err := s.Read()
+	return err
+}
+
+// Warning explains that X11 sees only XWayland windows in a Wayland session.
+// The X11 source is only chosen there when the Window Calls extension is
+// missing, and native Wayland Figma windows then go unnoticed.
+func (x11TitleSource) Warning() string {
+	if os.Getenv("WAYLAND_DISPLAY") == "" {
+		return ""
+	}
+	return "this is a Wayland session but the GNOME Shell \"Window Calls\" extension is not available, " +
+		"so only XWayland windows are visible. Install it from https://extensions.gnome.org/extension/4724/window-calls/ to see native Figma windows."
+}
+
+// Read lists Figma windows: those whose title ends in "– Figma" or "- FigJam",
+// and those of the unofficial figma-linux client by WM_CLASS. Browsers append
+// their own name to the tab title, so Figma in a browser is not found.
+func (x11TitleSource) Read() (TitleResult, error) {
+	windows, err := listX11FigmaWindows()
+	if err != nil {
+		return TitleResult{}, err
+	}
+	return TitleResult{Windows: windows}, nil
+}
+
+// listX11FigmaWindows asks the X server in $DISPLAY for every managed window.
+func listX11FigmaWindows() ([]FigmaWindow, error) {
+	if x11Conn == nil {
+		conn, err := xgb.NewConn()
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to X server: %w", err)
+		}
+		x11Conn = conn
+	}
+
+	windows, err := listFigmaWindows(x11Conn)
+	if err != nil {
+		x11Conn.Close()
+		x11Conn = nil
+		return nil, err
+	}
+
+	return windows, nil
+}
+
+// listFigmaWindows walks _NET_CLIENT_LIST on the default root window and
+// returns the windows that belong to Figma, flagging _NET_ACTIVE_WINDOW. It
+// fails when no EWMH window manager is running, so the source is not chosen.
+func listFigmaWindows(conn *xgb.Conn) ([]FigmaWindow, error) {
+	root := xproto.Setup(conn).DefaultScreen(conn).Root
+
+	clientListAtom, err := internAtom(conn, "_NET_CLIENT_LIST")
+	if err != nil {
+		return nil, err
+	}
+	netWMNameAtom, err := internAtom(conn, "_NET_WM_NAME")
+	if err != nil {
+		return nil, err
+	}
+	utf8StringAtom, err := internAtom(conn, "UTF8_STRING")
+	if err != nil {
+		return nil, err
+	}
+	activeWindowAtom, err := internAtom(conn, "_NET_ACTIVE_WINDOW")
+	if err != nil {
+		return nil, err
+	}
+
+	var activeWindow xproto.Window
+	activeReply, err := xproto.GetProperty(conn, false, root, activeWindowAtom, xproto.AtomWindow, 0, 1).Reply()
+	if err == nil && activeReply.Format == 32 && len(activeReply.Value) >= 4 {
+		activeWindow = xproto.Window(xgb.Get32(activeReply.Value))
+	}
+
+	reply, err := xproto.GetProperty(conn, false, root, clientListAtom, xproto.AtomWindow, 0, 1024).Reply()
+	if err != nil {
+		return nil, fmt.Errorf("could not read _NET_CLIENT_LIST: %w", err)
+	}
+	if reply.Format != 32 {
+		// Only an EWMH window manager maintains the list. Without one no
+		// window is ever found, which must not pass for Figma being closed.
+		return nil, errors.New("_NET_CLIENT_LIST is not set; no EWMH window manager is running")
+	}
+
+	windows := make([]FigmaWindow, 0, 4)
+	for i := 0; i+4 <= len(reply.Value); i += 4 {
+		window := xproto.Window(xgb.Get32(reply.Value[i:]))
+
+		title := readStringProperty(conn, window, netWMNameAtom, utf8StringAtom)
+		if title == "" {
+			title = readStringProperty(conn, window, xproto.AtomWmName, xproto.AtomString)
+		}
+		if title == "" {
+			continue
+		}
+
+		if isFigmaWindowTitle(title) || isFigmaWindowClass(conn, window) {
+			windows = append(windows, FigmaWindow{Title: title, Focused: window == activeWindow})
+		}
+	}
+
+	return windows, nil
+}
+
+func internAtom(conn *xgb.Conn, name string) (xproto.Atom, error) {
+	reply, err := xproto.InternAtom(conn, false, uint16(len(name)), name).Reply()
+	if err != nil {
+		return 0, fmt.Errorf("could not intern atom %s: %w", name, err)
+	}
+	return reply.Atom, nil
+}
+
+// readStringProperty returns a text property of a window, or "" if it is
+// missing. Windows can disappear between listing and reading, so errors are
+// treated as an empty value.
+func readStringProperty(conn *xgb.Conn, window xproto.Window, property, propertyType xproto.Atom) string {
+	reply, err := xproto.GetProperty(conn, false, window, property, propertyType, 0, 256).Reply()
+	if err != nil || reply.Format != 8 {
+		return ""
+	}
+	return strings.TrimSpace(string(reply.Value))
+}
+
+// figmaLinuxClass is the WM_CLASS instance and class of the figma-linux
+// client. It is matched exactly, so helper windows whose class merely
+// contains "figma" are left out.
+const figmaLinuxClass = "figma-linux"
+
+// isFigmaWindowClass reports whether WM_CLASS identifies the figma-linux client.
+func isFigmaWindowClass(conn *xgb.Conn, window xproto.Window) bool {
+	reply, err := xproto.GetProperty(conn, false, window, xproto.AtomWmClass, xproto.AtomString, 0, 256).Reply()
+	if err != nil || reply.Format != 8 {
+		return false
+	}
+	// WM_CLASS is the instance and the class, each NUL-terminated.
+	for _, name := range strings.Split(string(reply.Value), "\x00") {
+		if isFigmaLinuxClass(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFigmaLinuxClass compares a window class or instance name exactly.
+func isFigmaLinuxClass(name string) bool {
+	return strings.EqualFold(name, figmaLinuxClass)
+}
+
+// x11IdleConn is separate from x11Conn because the idle monitor runs on its
+// own goroutine.
+var x11IdleConn *xgb.Conn
+
+// systemIdleTime returns the time since the last keyboard or mouse input,
+// using the X11 MIT-SCREEN-SAVER extension and falling back to Mutter's idle
+// monitor on GNOME Wayland sessions.
+func systemIdleTime() (time.Duration, error) {
+	idle, x11Err := x11IdleTime()
+	if x11Err == nil {
+		return idle, nil
+	}
+
+	idle, mutterErr := mutterIdleTime()
+	if mutterErr == nil {
+		return idle, nil
+	}
+
+	return 0, fmt.Errorf("%v; %v", x11Err, mutterErr)
+}
+
+func x11IdleTime() (time.Duration, error) {
+	if x11IdleConn == nil {
+		conn, err := xgb.NewConn()
+		if err != nil {
+			return 0, fmt.Errorf("could not connect to X server: %w", err)
+		}
+		if err := screensaver.Init(conn); err != nil {
+			conn.Close()
+			return 0, fmt.Errorf("MIT-SCREEN-SAVER extension unavailable: %w", err)
+		}
+		x11IdleConn = conn
+	}
+
+	root := xproto.Setup(x11IdleConn).DefaultScreen(x11IdleConn).Root
+	reply, err := screensaver.QueryInfo(x11IdleConn, xproto.Drawable(root)).Reply()
+	if err != nil {
+		x11IdleConn.Close()
+		x11IdleConn = nil
+		return 0, fmt.Errorf("screensaver query failed: %w", err)
+	}
+
+	return time.Duration(reply.MsSinceUserInput) * time.Millisecond, nil
+}
+
+// attachParentConsole is only needed on Windows, where the GUI build has no
+// console.
+func attachParentConsole() {}
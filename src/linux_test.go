@@ -0,0 +1,141 @@
+//go:build linux
+
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jezek/xgb"
+	"github.com/jezek/xgb/xproto"
+)
+
+// TestX11TitleSource needs an X server without a window manager, e.g.
+//
+//	xvfb-run go test -run X11 .
+//
+// The test stands in for the window manager by writing _NET_CLIENT_LIST and
+// _NET_ACTIVE_WINDOW itself.
+func TestX11TitleSource(t *testing.T) {
+	if os.Getenv("DISPLAY") == "" {
+		t.Skip("DISPLAY is not set")
+	}
+	conn, err := xgb.NewConn()
+	if err != nil {
+		t.Skip("no X server:", err)
+	}
+	defer conn.Close()
+
+	screen := xproto.Setup(conn).DefaultScreen(conn)
+	root := screen.Root
+	if wmCheck := mustInternAtom(t, conn, "_NET_SUPPORTING_WM_CHECK"); hasProperty(conn, root, wmCheck) {
+		t.Skip("a window manager is running; run under a bare X server such as xvfb-run")
+	}
+
+	utf8String := mustInternAtom(t, conn, "UTF8_STRING")
+	netWMName := mustInternAtom(t, conn, "_NET_WM_NAME")
+	createWindow := func(title string, titleProperty, titleType xproto.Atom) xproto.Window {
+		window, err := xproto.NewWindowId(conn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := xproto.CreateWindowChecked(conn, screen.RootDepth, window, root, 0, 0, 10, 10, 0,
+			xproto.WindowClassInputOutput, screen.RootVisual, 0, nil).Check(); err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { xproto.DestroyWindow(conn, window) })
+		setProperty(t, conn, window, titleProperty, titleType, 8, []byte(title))
+		return window
+	}
+
+	logo := createWindow("Logo – Figma", netWMName, utf8String)
+	board := createWindow("Sprint Retro - FigJam", xproto.AtomWmName, xproto.AtomString)
+	other := createWindow("Terminal", netWMName, utf8String)
+	client := createWindow("Figma", netWMName, utf8String)
+	setProperty(t, conn, client, xproto.AtomWmClass, xproto.AtomString, 8, []byte("figma-linux\x00figma-linux\x00"))
+	updater := createWindow("Updating", netWMName, utf8String)
+	setProperty(t, conn, updater, xproto.AtomWmClass, xproto.AtomString, 8, []byte("figma-linux-updater\x00Figma-linux-updater\x00"))
+
+	// Without a window manager there is no client list, which is an error
+	// rather than a desktop without Figma.
+	source := x11TitleSource{}
+	if err := source.Probe(); err == nil {
+		t.Error("Probe succeeded without _NET_CLIENT_LIST")
+	}
+
+	clientList := mustInternAtom(t, conn, "_NET_CLIENT_LIST")
+	activeWindow := mustInternAtom(t, conn, "_NET_ACTIVE_WINDOW")
+	setProperty(t, conn, root, clientList, xproto.AtomWindow, 32, windowList(logo, board, other, client, updater))
+	setProperty(t, conn, root, activeWindow, xproto.AtomWindow, 32, windowList(board))
+	t.Cleanup(func() {
+		xproto.DeleteProperty(conn, root, clientList)
+		xproto.DeleteProperty(conn, root, activeWindow)
+		conn.Sync()
+	})
+
+	if err := source.Probe(); err != nil {
+		t.Fatal("Probe:", err)
+	}
+	result, err := source.Read()
+	if err != nil {
+		t.Fatal("Read:", err)
+	}
+
+	want := []FigmaWindow{{Title: "Logo – Figma"}, {Title: "Sprint Retro - FigJam", Focused: true}, {Title: "Figma"}}
+	if len(result.Windows) != len(want) {
+		t.Fatalf("windows = %+v, want %+v", result.Windows, want)
+	}
+	for i := range want {
+		if result.Windows[i] != want[i] {
+			t.Errorf("window %d = %+v, want %+v", i, result.Windows[i], want[i])
+		}
+	}
+}
+
+func TestFigmaLinuxClass(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"figma-linux", true},
+		{"Figma-linux", true},
+		{"figma-linux-updater", false},
+		{"figma-agent", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isFigmaLinuxClass(tt.name); got != tt.want {
+			t.Errorf("isFigmaLinuxClass(%q) = %t, want %t", tt.name, got, tt.want)
+		}
+	}
+}
+
+func mustInternAtom(t *testing.T, conn *xgb.Conn, name string) xproto.Atom {
+	t.Helper()
+	atom, err := internAtom(conn, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return atom
+}
+
+func hasProperty(conn *xgb.Conn, window xproto.Window, property xproto.Atom) bool {
+	reply, err := xproto.GetProperty(conn, false, window, property, xproto.GetPropertyTypeAny, 0, 1).Reply()
+	return err == nil && reply.Format != 0
+}
+
+func setProperty(t *testing.T, conn *xgb.Conn, window xproto.Window, property, propertyType xproto.Atom, format byte, data []byte) {
+	t.Helper()
+	length := uint32(len(data)) / uint32(format/8)
+	if err := xproto.ChangePropertyChecked(conn, xproto.PropModeReplace, window, property, propertyType, format, length, data).Check(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func windowList(windows ...xproto.Window) []byte {
+	data := make([]byte, 4*len(windows))
+	for i, window := range windows {
+		xgb.Put32(data[4*i:], uint32(window))
+	}
+	return data
+}
@@ -4,29 +4,92 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"reflect"
 	"sync"
 	"syscall"
 	"time"
 
-	"github.com/hugolgst/rich-go/client"
+	"Figma-Discord-Rich-Presence/discordipc"
 )
 
-// Replace with your own public key if desired.
+// discordClientID is the bundled Discord application, used unless the
+// client_id setting names another one.
 const discordClientID = "1473014472498086092"
 const appVersion = "2.0.0"
 
+const (
+	clearPolicyClear      = "clear"      // Send an empty activity and keep the connection
+	clearPolicyDisconnect = "disconnect" // Close the connection; reconnect for the next file
+)
+
+// connectionCheckInterval is how often an unchanged activity is re-sent to
+// find out whether Discord is still there.
+const connectionCheckInterval = 30 * time.Second
+
+// discordClient is the part of a Discord IPC connection the RPC manager uses.
+type discordClient interface {
+	SetActivity(activity *discordipc.Activity) error
+	User() discordipc.User
+	Close() error
+}
+
+// discordDialer opens a Discord connection for an application ID.
+type discordDialer func(clientID string) (discordClient, error)
+
+// dialDiscord connects to the local Discord client over its IPC socket.
+func dialDiscord(clientID string) (discordClient, error) {
+	conn, err := discordipc.Dial(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if user := conn.User(); user.Username != "" {
+		fmt.Println("Connected to Discord as", user.Username)
+	}
+	return conn, nil
+}
+
 type rpcManagerState struct {
-	clientID        string
-	rpcEnabled      bool
-	privacyMode     bool
-	customLabel     string
-	connected       bool
-	sessionStart    time.Time
-	currentFilename string
+	presence        *presenceMachine
+	connector       *discordConnector
+	conn            discordClient // nil while disconnected
+	options         presenceOptions
+	clearPolicy     string
+	timer           *sessionTimer
+	currentState    FigmaState
+	figmaKnown      bool // currentState comes from the poller, not the zero value
 	lastActivitySig string
+	limiter         *activityLimiter
+	pendingForce    bool   // A forced update is waiting on the rate limiter
+	publishedLabel  string // What the published activity shows, for the status line
+	lastError       string // Last activity Discord rejected, cleared by the next publish
+	lastStatus      rpcStatus
+
+	idle       idleDetector
+	idleAction string
+	isIdle     bool
+	idleSince  time.Time
+
+	journal *workJournal // nil while the journal is disabled
+
+	pause        pauseState
+	pauseExpiry  <-chan time.Time // Fires when a timed pause ends; nil otherwise
+	pauseModTime time.Time        // Of pause.json as last read or written
+
+	dayRollover <-chan time.Time // Fires at midnight in the day timer mode; nil otherwise
 }
 
 func main() {
+	args := os.Args[1:]
+	if len(args) > 0 && !launchedByFinder(args) {
+		attachParentConsole()
+	}
+	os.Exit(runCLI(args, os.Stdout, os.Stderr))
+}
+
+// runApp starts the poller, idle monitor and RPC manager. With the UI it runs
+// the Fyne event loop on the main goroutine; headless it just waits for a
+// signal. Either way SIGINT/SIGTERM drive shutdown.
+func runApp(headless bool) int {
 	fmt.Printf("Figma Discord Rich Presence v%s\n", appVersion)
 
 	cfg, err := LoadConfig()
@@ -35,43 +98,94 @@ func main() {
 	}
 
 	events := NewUIEvents()
-	ui := SetupUI(cfg, events)
+	var ui *AppUI
+	if !headless {
+		ui = SetupUI(cfg, events)
+	}
 
 	stop := make(chan struct{})
-	filenameUpdates := make(chan string, 1)
+	stateUpdates := make(chan FigmaState, 1)
+	idleUpdates := make(chan time.Duration, 1)
 	var wg sync.WaitGroup
 
 	wg.Add(1)
-	go runFigmaPoller(filenameUpdates, stop, &wg)
+	go runFigmaPoller(nil, stateUpdates, stop, &wg)
+
+	wg.Add(1)
+	go runIdleMonitor(idleUpdates, stop, &wg)
 
 	wg.Add(1)
-	go runRPCManager(discordClientID, cfg, events, filenameUpdates, stop, &wg)
+	go runRPCManager(cfg.ClientID, dialDiscord, cfg.Clone(), events, stateUpdates, idleUpdates, stop, &wg)
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
+
+	if headless {
+		fmt.Println("Figma Discord Rich Presence is running headless. Press Ctrl+C to stop.")
 		<-sigChan
 		fmt.Println("\nShutting down...")
-		ui.App.Quit()
-	}()
-
-	fmt.Println("Figma Discord Rich Presence is running...")
-	ui.Run()
+	} else {
+		go func() {
+			<-sigChan
+			fmt.Println("\nShutting down...")
+			ui.App.Quit()
+		}()
+
+		fmt.Println("Figma Discord Rich Presence is running...")
+		ui.Run()
+	}
 
 	close(stop)
 	wg.Wait()
 	fmt.Println("Exited cleanly.")
+	return 0
 }
 
-func runFigmaPoller(filenameUpdates chan string, stop <-chan struct{}, wg *sync.WaitGroup) {
+const figmaPollInterval = 1 * time.Second
+
+// titleSourceFailureLimit is how many reads in a row may fail before the
+// sources are probed again, e.g. after the X server behind $DISPLAY went away.
+const titleSourceFailureLimit = 10
+
+// figmaPoller turns raw TitleSource reads into debounced FigmaState updates.
+// It is driven one step at a time so it can be exercised with a scripted source.
+type figmaPoller struct {
+	source       TitleSource
+	selectSource func() (TitleSource, error)
+	interval     time.Duration
+	now          func() time.Time
+	log          func(a ...any)
+
+	readFailures  int // Consecutive failed reads of source
+	lastReadErr   string
+	lastReadErrAt time.Time
+	emptyPolls    int
+	lastSent      FigmaState
+	hasLastSent   bool
+}
+
+// newFigmaPoller creates a poller. A nil source is selected from the registry
+// on the first step and re-selected until one works. A source that keeps
+// failing is replaced the same way.
+func newFigmaPoller(source TitleSource) *figmaPoller {
+	return &figmaPoller{
+		source:       source,
+		selectSource: SelectTitleSource,
+		interval:     figmaPollInterval,
+		now:          time.Now,
+		log:          func(a ...any) { fmt.Println(a...) },
+	}
+}
+
+func runFigmaPoller(source TitleSource, stateUpdates chan FigmaState, stop <-chan struct{}, wg *sync.WaitGroup) {
 	defer wg.Done()
 
-	lastReadErr := ""
-	lastReadErrAt := time.Time{}
-	emptyPolls := 0
-	lastSent := ""
-	hasLastSent := false
+	newFigmaPoller(source).run(stateUpdates, stop)
+}
 
+// run polls every interval until stop is closed, sending changes to
+// stateUpdates.
+func (p *figmaPoller) run(stateUpdates chan FigmaState, stop <-chan struct{}) {
 	for {
 		select {
 		case <-stop:
@@ -79,246 +193,721 @@ func runFigmaPoller(filenameUpdates chan string, stop <-chan struct{}, wg *sync.
 		default:
 		}
 
-		filename, err := GetFigmaTitle()
-		if err != nil {
-			errMsg := err.Error()
-			nowErr := time.Now()
-			if errMsg != lastReadErr || nowErr.Sub(lastReadErrAt) >= 30*time.Second {
-				fmt.Println("Error reading Figma title:", err)
-				lastReadErr = errMsg
-				lastReadErrAt = nowErr
-			}
-			if !sleepWithStop(1*time.Second, stop) {
-				return
-			}
-			continue
+		if figma, changed := p.step(); changed {
+			pushLatestState(stateUpdates, figma)
 		}
-		lastReadErr = ""
-		lastReadErrAt = time.Time{}
 
-		if filename == "" {
-			emptyPolls++
-			if emptyPolls == 1 && hasLastSent && lastSent != "" {
-				fmt.Println("Figma title temporarily unavailable, waiting for confirmation...")
-			}
-			if emptyPolls < 3 {
-				if !sleepWithStop(1*time.Second, stop) {
-					return
-				}
-				continue
-			}
-		} else {
-			if emptyPolls > 0 && hasLastSent && lastSent != "" {
-				fmt.Println("Figma title recovered.")
-			}
-			emptyPolls = 0
+		if !sleepWithStop(p.interval, stop) {
+			return
 		}
+	}
+}
 
-		if !hasLastSent || filename != lastSent {
-			pushLatestFilename(filenameUpdates, filename)
-			lastSent = filename
-			hasLastSent = true
+// step performs a single poll and returns the state to publish, if it changed.
+// A closed Figma must be seen on three consecutive polls before it is
+// published, so a briefly missing window does not clear the presence.
+func (p *figmaPoller) step() (FigmaState, bool) {
+	if p.source == nil {
+		source, err := p.selectSource()
+		if err != nil {
+			p.reportReadError(err)
+			return FigmaState{}, false
 		}
+		p.source = source
+		p.log("Reading Figma window titles via", source.Name())
+		if warning := titleSourceWarning(source); warning != "" {
+			p.log("Warning:", warning)
+		}
+	}
 
-		if !sleepWithStop(1*time.Second, stop) {
-			return
+	result, err := p.source.Read()
+	if err != nil {
+		p.reportReadError(err)
+		p.readFailures++
+		if p.readFailures >= titleSourceFailureLimit {
+			p.log("Reading via", p.source.Name(), "keeps failing, probing the title sources again")
+			p.source = nil
+			p.readFailures = 0
+		}
+		return FigmaState{}, false
+	}
+	p.readFailures = 0
+	p.lastReadErr = ""
+	p.lastReadErrAt = time.Time{}
+
+	figma := parseFigmaState(result.Windows, p.lastSent.RawTitle)
+	if figma.Mode == ModeClosed {
+		p.emptyPolls++
+		if p.emptyPolls == 1 && p.hasLastSent && p.lastSent.Mode != ModeClosed {
+			p.log("Figma title temporarily unavailable, waiting for confirmation...")
+		}
+		if p.emptyPolls < 3 {
+			return FigmaState{}, false
 		}
+	} else {
+		if p.emptyPolls > 0 && p.hasLastSent && p.lastSent.Mode != ModeClosed {
+			p.log("Figma title recovered.")
+		}
+		p.emptyPolls = 0
+	}
+
+	if p.hasLastSent && figma == p.lastSent {
+		return FigmaState{}, false
+	}
+
+	p.lastSent = figma
+	p.hasLastSent = true
+	return figma, true
+}
+
+// reportReadError logs a read error, repeating an identical one at most every 30s.
+func (p *figmaPoller) reportReadError(err error) {
+	errMsg := err.Error()
+	nowErr := p.now()
+	if errMsg != p.lastReadErr || nowErr.Sub(p.lastReadErrAt) >= 30*time.Second {
+		p.log("Error reading Figma title:", err)
+		p.lastReadErr = errMsg
+		p.lastReadErrAt = nowErr
 	}
 }
 
-func runRPCManager(clientID string, cfg *Config, events *UIEvents, filenameUpdates <-chan string, stop <-chan struct{}, wg *sync.WaitGroup) {
+// runRPCManager owns the Discord connection and the presence state machine.
+// observers are subscribed to every presence transition, next to the log.
+func runRPCManager(clientID string, dial discordDialer, cfg *Config, events *UIEvents, stateUpdates <-chan FigmaState, idleUpdates <-chan time.Duration, stop <-chan struct{}, wg *sync.WaitGroup, observers ...func(presenceTransition)) {
 	defer wg.Done()
 
 	state := rpcManagerState{
-		clientID:        clientID,
-		rpcEnabled:      cfg.RPCEnabled,
-		privacyMode:     cfg.PrivacyMode,
-		customLabel:     sanitizeCustomLabel(cfg.CustomLabel),
-		connected:       false,
-		sessionStart:    time.Now(),
-		currentFilename: "",
+		presence:        newPresenceMachine(cfg.RPCEnabled, time.Now),
+		options:         presenceOptionsFromConfig(cfg),
+		clearPolicy:     sanitizeClearPolicy(cfg.ClearPolicy),
+		connector:       newDiscordConnector(clientID, dial),
+		timer:           newSessionTimer(cfg.TimerMode, time.Now),
+		currentState:    FigmaState{},
 		lastActivitySig: "",
+		limiter:         newActivityLimiter(time.Now, time.After),
+		idle: idleDetector{
+			inputThreshold:  minutesToDuration(cfg.IdleTimeoutMinutes),
+			titleThreshold:  minutesToDuration(cfg.UnchangedTitleMinutes),
+			lastTitleChange: time.Now(),
+		},
+		idleAction: sanitizeIdleAction(cfg.IdleAction),
+	}
+	state.presence.Subscribe(logPresenceTransition)
+	for _, observer := range observers {
+		state.presence.Subscribe(observer)
 	}
 
-	if !state.rpcEnabled {
+	setJournalEnabled(&state, cfg.JournalEnabled)
+	armDayRollover(&state)
+
+	if pause, modTime, err := loadPause(); err != nil {
+		fmt.Println("Could not load pause:", err)
+	} else if pause.Active(time.Now()) {
+		state.pauseModTime = modTime
+		setPause(&state, pause, false)
+	} else if !pause.Equal(pauseState{}) {
+		if _, err := savePause(pauseState{}); err != nil {
+			fmt.Println("Could not clear expired pause:", err)
+		}
+	}
+
+	if !cfg.RPCEnabled {
 		fmt.Println("RPC is disabled in settings. Waiting for Reconnect.")
 	}
 
+	// Discord never tells an idle client that it went away, so the current
+	// activity is re-sent periodically to notice a restart.
+	connectionCheck := time.NewTicker(connectionCheckInterval)
+	defer connectionCheck.Stop()
+
+	pauseCheck := time.NewTicker(pauseCheckInterval)
+	defer pauseCheck.Stop()
+
+	journalCheckpoint := time.NewTicker(journalCheckpointInterval)
+	defer journalCheckpoint.Stop()
+
 	for {
+		reportStatus(&state, events.Status)
+
 		select {
 		case <-stop:
-			if state.connected {
-				client.Logout()
-			}
+			setJournalEnabled(&state, false)
+			state.connector.Stop()
+			closeConnection(&state)
 			return
 
 		case <-events.Disconnect:
-			state.rpcEnabled = false
-			state.lastActivitySig = ""
-			if state.connected {
-				fmt.Println("Disconnecting from Discord RPC.")
-				client.Logout()
-				state.connected = false
-			}
+			setRPCEnabled(&state, false, "Disconnect clicked")
 
 		case <-events.Reconnect:
-			state.rpcEnabled = true
-			state.sessionStart = time.Now()
+			if !setRPCEnabled(&state, true, "Reconnect clicked") {
+				state.timer.Reset()
+				syncActivity(&state, true)
+			}
+
+		case pause := <-events.Pause:
+			setPause(&state, pause, true)
+
+		case <-state.pauseExpiry:
+			if !state.pause.Active(time.Now()) {
+				setPause(&state, pauseState{}, true)
+			} else {
+				// The timer fired early, e.g. after a wall clock change;
+				// arm it again so the pause still ends.
+				state.pauseExpiry = time.After(time.Until(state.pause.Until))
+			}
+
+		case <-pauseCheck.C:
+			checkPauseFile(&state)
+
+		case <-journalCheckpoint.C:
+			if state.journal != nil {
+				state.journal.Checkpoint()
+			}
+
+		case <-state.dayRollover:
+			// The day timer starts over, which the activity signature
+			// doesn't cover.
+			armDayRollover(&state)
+			syncActivity(&state, true)
+
+		case result := <-state.connector.Results():
+			conn, err := state.connector.Accept(result)
+			if err != nil {
+				state.lastError = err.Error()
+				continue
+			}
+			if conn == nil {
+				continue
+			}
+			state.conn = conn
 			state.lastActivitySig = ""
-			syncActivity(&state, stop, true)
+			state.lastError = ""
+			state.presence.Fire(presenceConnected, "")
+			syncActivity(&state, true)
 
 		case updated := <-events.ConfigChanged:
 			if updated == nil {
 				continue
 			}
 
-			prevRPCEnabled := state.rpcEnabled
-			prevPrivacyMode := state.privacyMode
-			prevLabel := state.customLabel
-
-			state.rpcEnabled = updated.RPCEnabled
-			state.privacyMode = updated.PrivacyMode
-			state.customLabel = sanitizeCustomLabel(updated.CustomLabel)
-
-			if !state.rpcEnabled {
-				state.lastActivitySig = ""
-				if state.connected {
-					fmt.Println("RPC disabled from settings.")
-					client.Logout()
-					state.connected = false
-				}
+			prevOptions := state.options
+			prevIdleAction := state.idleAction
+			prevClearPolicy := state.clearPolicy
+
+			state.options = presenceOptionsFromConfig(updated)
+			state.idleAction = sanitizeIdleAction(updated.IdleAction)
+			state.clearPolicy = sanitizeClearPolicy(updated.ClearPolicy)
+			state.idle.inputThreshold = minutesToDuration(updated.IdleTimeoutMinutes)
+			state.idle.titleThreshold = minutesToDuration(updated.UnchangedTitleMinutes)
+			prevTimerStart := state.timer.Start()
+			state.timer.SetMode(updated.TimerMode)
+			armDayRollover(&state)
+			setJournalEnabled(&state, updated.JournalEnabled)
+			recordJournal(&state)
+
+			if updated.ClientID != state.connector.clientID {
+				setClientID(&state, updated.ClientID)
+			}
+
+			if setRPCEnabled(&state, updated.RPCEnabled, "changed in settings") {
 				continue
 			}
 
-			if !prevRPCEnabled && state.rpcEnabled {
-				state.rpcEnabled = true
-				state.sessionStart = time.Now()
-				state.lastActivitySig = ""
-				syncActivity(&state, stop, true)
+			if updateIdle(&state) {
+				syncActivity(&state, true)
 				continue
 			}
 
-			if prevPrivacyMode != state.privacyMode || prevLabel != state.customLabel {
-				syncActivity(&state, stop, true)
+			if !reflect.DeepEqual(prevOptions, state.options) || (state.isIdle && prevIdleAction != state.idleAction) ||
+				prevClearPolicy != state.clearPolicy || !prevTimerStart.Equal(state.timer.Start()) {
+				syncActivity(&state, true)
 			}
 
-		case filename := <-filenameUpdates:
-			state.currentFilename = filename
-			syncActivity(&state, stop, false)
+		case figma := <-stateUpdates:
+			if figma.RawTitle != state.currentState.RawTitle {
+				state.idle.lastTitleChange = time.Now()
+			}
+			state.currentState = figma
+			state.figmaKnown = true
+			if state.pause.UntilFigmaCloses && figma.Mode == ModeClosed {
+				setPause(&state, pauseState{}, true)
+			}
+			state.timer.Switch(timerKey(figma))
+			updateIdle(&state)
+			recordJournal(&state)
+			syncActivity(&state, false)
+
+		case inputIdle := <-idleUpdates:
+			state.idle.inputIdle = inputIdle
+			if updateIdle(&state) {
+				recordJournal(&state)
+				syncActivity(&state, true)
+			}
+
+		case <-state.limiter.Ready():
+			state.limiter.Fired()
+			syncActivity(&state, state.pendingForce)
+
+		case <-connectionCheck.C:
+			if state.conn != nil && state.lastActivitySig != "" {
+				syncActivity(&state, true)
+			}
 		}
 	}
 }
 
-func syncActivity(state *rpcManagerState, stop <-chan struct{}, force bool) {
-	if !state.rpcEnabled {
+// setRPCEnabled turns publishing on or off and reports whether that changed
+// anything. Turning it on starts a new timer session and publishes right away.
+func setRPCEnabled(state *rpcManagerState, enabled bool, reason string) bool {
+	if enabled == (state.presence.State() != presenceDisabled) {
+		return false
+	}
+
+	if !enabled {
+		state.connector.Stop()
+		closeConnection(state)
+		state.lastActivitySig = ""
+		state.lastError = ""
+		state.presence.Fire(presenceDisable, reason)
+		return true
+	}
+
+	state.timer.Reset()
+	state.lastActivitySig = ""
+	state.presence.Fire(presenceEnable, reason)
+	syncActivity(state, true)
+	return true
+}
+
+// setClientID switches to another Discord application. The presence belongs
+// to the application, so the old connection is closed and the current
+// activity is published again under the new one.
+func setClientID(state *rpcManagerState, clientID string) {
+	fmt.Println("Discord application ID changed to", clientID)
+	state.connector.Stop()
+	state.connector.clientID = clientID
+	state.lastError = ""
+	if state.conn != nil {
+		closeConnection(state)
+		state.lastActivitySig = ""
+		state.presence.Fire(presenceConnectionLost, "Discord application changed")
+	}
+	syncActivity(state, true)
+}
+
+// setPause starts, changes or ends (with the zero pause) a pause and
+// updates the presence. With save set the pause is written to pause.json.
+// A pause until Figma closes is refused while Figma is known to be closed,
+// since nothing would end it; the current pause, if any, stays.
+func setPause(state *rpcManagerState, pause pauseState, save bool) {
+	if pause.UntilFigmaCloses && state.figmaKnown && state.currentState.Mode == ModeClosed {
+		fmt.Println("Figma is not open, so there is nothing to pause until it closes.")
+		// The CLI may have written the refused pause; put back the one in effect.
+		modTime, err := savePause(state.pause)
+		if err != nil {
+			fmt.Println("Could not save pause:", err)
+		}
+		state.pauseModTime = modTime
 		return
 	}
 
-	if state.currentFilename == "" {
-		if state.connected {
-			fmt.Println("Figma closed or no file open. Clearing presence.")
-			client.Logout()
-			state.connected = false
+	state.pause = pause
+	state.pauseExpiry = nil
+	if !pause.Until.IsZero() {
+		state.pauseExpiry = time.After(time.Until(pause.Until))
+	}
+
+	if save {
+		modTime, err := savePause(pause)
+		if err != nil {
+			fmt.Println("Could not save pause:", err)
 		}
-		state.lastActivitySig = ""
+		state.pauseModTime = modTime
+	}
+
+	if pause.Active(time.Now()) {
+		fmt.Println("Presence paused", pause.Describe())
+	} else {
+		fmt.Println("Presence resumed.")
+	}
+	syncActivity(state, true)
+}
+
+// armDayRollover schedules the next midnight in the day timer mode, when the
+// timer starts over and the activity has to be published again.
+func armDayRollover(state *rpcManagerState) {
+	state.dayRollover = nil
+	if next := state.timer.NextRollover(); !next.IsZero() {
+		state.dayRollover = time.After(time.Until(next))
+	}
+}
+
+// checkPauseFile applies a pause written by `figma-rpc pause` or
+// `figma-rpc resume` while the app runs.
+func checkPauseFile(state *rpcManagerState) {
+	pause, modTime, err := loadPause()
+	if err != nil || modTime.Equal(state.pauseModTime) {
+		return
+	}
+	state.pauseModTime = modTime
+	if !pause.Active(time.Now()) {
+		pause = pauseState{}
+	}
+	if !pause.Equal(state.pause) {
+		setPause(state, pause, false)
+	}
+}
+
+// syncActivity brings Discord in line with the current Figma and idle state,
+// driving the presence state machine as it goes. Unless force is set, an
+// unchanged activity is not sent again.
+func syncActivity(state *rpcManagerState, force bool) {
+	if state.presence.State() == presenceDisabled {
+		return
+	}
+
+	if state.pause.Active(time.Now()) {
+		clearPresence(state, presencePause, "paused "+state.pause.Describe())
+		return
+	}
+
+	if state.currentState.Mode == ModeClosed {
+		clearPresence(state, presenceClear, "Figma closed or no file open")
+		return
+	}
+
+	figma := state.options.normalizedState(state.currentState)
+	privacy := state.options.privacyFor(figma.File)
+	if privacy.Action == privacyActionHide {
+		clearPresence(state, presenceClear, "file hidden by a privacy rule")
 		return
 	}
 
-	if !ensureConnected(state, stop) {
+	if state.isIdle && state.idleAction == idleActionClear {
+		clearPresence(state, presencePause, "user is away")
 		return
 	}
 
-	activity := activityFromFilename(state.currentFilename, state.privacyMode, state.customLabel, state.sessionStart)
+	if state.conn == nil {
+		state.connector.Start()
+		state.presence.Fire(presenceConnecting, "")
+		return
+	}
+
+	var idleSince time.Time
+	if state.isIdle {
+		idleSince = state.idleSince
+	}
+	activity := activityFromFilename(figma, privacy, state.options, state.timer.Start(), idleSince)
 	signature := activitySignature(activity)
 	if !force && signature == state.lastActivitySig {
 		return
 	}
+	if !state.limiter.Allow() {
+		// Over Discord's rate limit. The latest state is sent once the
+		// limiter has budget again.
+		state.pendingForce = state.pendingForce || force
+		return
+	}
+	state.pendingForce = false
 
-	fmt.Println("State changed:", state.currentFilename)
-	if err := client.SetActivity(activity); err != nil {
+	if err := state.conn.SetActivity(&activity); err != nil {
+		if discordipc.IsConnectionError(err) {
+			// Discord quit or restarted. The activity is replayed once the
+			// background reconnect succeeds.
+			closeConnection(state)
+			state.lastActivitySig = ""
+			state.connector.Start()
+			state.presence.Fire(presenceConnectionLost, err.Error())
+			return
+		}
 		fmt.Println("Failed to set activity:", err)
+		state.lastError = err.Error()
 		return
 	}
 
+	state.lastError = ""
+	state.publishedLabel = activity.State
+	if state.publishedLabel == "" {
+		state.publishedLabel = activity.Details
+	}
+	if signature != state.lastActivitySig {
+		state.presence.Fire(presencePublish, fmt.Sprintf("%s %q", state.currentState.Mode, state.currentState.File))
+	}
 	state.lastActivitySig = signature
 }
 
-func ensureConnected(state *rpcManagerState, stop <-chan struct{}) bool {
-	if !state.rpcEnabled {
-		return false
-	}
-	if state.connected {
-		return true
+// clearPresence removes the activity. With the "clear" policy an empty
+// activity is sent and the connection stays open for the next file; with
+// "disconnect" the connection is closed. A pending connection attempt is
+// abandoned either way.
+func clearPresence(state *rpcManagerState, event presenceEvent, reason string) {
+	state.connector.Stop()
+	state.lastError = ""
+
+	if state.clearPolicy == clearPolicyDisconnect || state.conn == nil {
+		closeConnection(state)
+		state.lastActivitySig = ""
+		state.presence.Fire(clearedEvent(state, event), reason)
+		return
 	}
 
-	for {
-		err := client.Login(state.clientID)
-		if err == nil {
-			state.connected = true
-			return true
+	if state.lastActivitySig != "" {
+		if !state.limiter.Allow() {
+			return
 		}
-		fmt.Println("Waiting for Discord... retrying in 5s")
-		select {
-		case <-stop:
-			return false
-		case <-time.After(5 * time.Second):
+		if err := state.conn.SetActivity(nil); err != nil {
+			if !discordipc.IsConnectionError(err) {
+				fmt.Println("Failed to clear activity:", err)
+				return
+			}
+			// Discord is gone, which clears the presence just as well.
+			closeConnection(state)
 		}
+		state.lastActivitySig = ""
 	}
+	state.presence.Fire(clearedEvent(state, event), reason)
 }
 
-func activityFromFilename(filename string, privacyMode bool, customLabel string, start time.Time) client.Activity {
-	if privacyMode {
-		state := sanitizeCustomLabel(customLabel)
-		details := "Editing File"
-		smallImage := "edit"
-		smallText := "Editing"
+// clearedEvent reports a clear that leaves no connection behind as
+// presenceDisconnected, so the status doesn't claim one.
+func clearedEvent(state *rpcManagerState, event presenceEvent) presenceEvent {
+	if event == presenceClear && state.conn == nil {
+		return presenceDisconnected
+	}
+	return event
+}
 
-		if filename == "Browsing Files" {
-			details = "In Home"
-			smallImage = "folder"
-			smallText = "Browsing"
-		}
+// closeConnection drops the Discord connection, if any.
+func closeConnection(state *rpcManagerState) {
+	if state.conn == nil {
+		return
+	}
+	if err := state.conn.Close(); err != nil {
+		fmt.Println("Error closing Discord connection:", err)
+	}
+	state.conn = nil
+}
 
-		return client.Activity{
-			State:      state,
-			Details:    details,
-			LargeImage: "largeimageid",
-			LargeText:  "Figma",
-			SmallImage: smallImage,
-			SmallText:  smallText,
-			Timestamps: &client.Timestamps{
-				Start: &start,
-			},
+// setJournalEnabled opens or closes the local time-tracking journal.
+func setJournalEnabled(state *rpcManagerState, enabled bool) {
+	if !enabled {
+		if state.journal != nil {
+			state.journal.Close()
+			state.journal = nil
 		}
+		return
+	}
+	if state.journal != nil {
+		return
+	}
+
+	journal, err := newWorkJournal()
+	if err != nil {
+		fmt.Println("Could not open journal:", err)
+		return
+	}
+	state.journal = journal
+	recordJournal(state)
+}
+
+// recordJournal feeds the journal the current state under the cleaned-up
+// name the presence shows, with the privacy decision made on that name. Idle
+// time is not work, so it is recorded as closed.
+func recordJournal(state *rpcManagerState) {
+	if state.journal == nil {
+		return
+	}
+	if state.isIdle {
+		state.journal.Observe(FigmaState{}, privacyDecision{})
+		return
+	}
+	figma := state.options.normalizedState(state.currentState)
+	state.journal.Observe(figma, state.options.privacyFor(figma.File))
+}
+
+// updateIdle re-evaluates the idle detector and reports whether the idle
+// state flipped. The session start is left untouched, so the original
+// timestamp comes back when activity resumes.
+func updateIdle(state *rpcManagerState) bool {
+	idle, since := state.idle.evaluate(time.Now())
+	if idle == state.isIdle {
+		return false
+	}
+
+	state.isIdle = idle
+	if idle {
+		state.idleSince = since
+		fmt.Println("User is idle since", since.Format(time.Kitchen))
+	} else {
+		fmt.Println("Activity resumed.")
+	}
+	return true
+}
+
+// presenceOptions are the settings that shape the activity.
+type presenceOptions struct {
+	PrivacyMode    bool
+	CustomLabel    string
+	ShowOtherFiles bool
+	Assets         PresenceAssets
+	Buttons        []PresenceButton
+	PrivacyRules   privacyRuleSet
+	Redactions     redactionSet
+	Cleanup        fileNameCleaner
+
+	Templates        PresenceTemplates
+	PrivacyTemplates PresenceTemplates
+}
+
+// presenceOptionsFromConfig builds the options, compiling the privacy rules,
+// redactions and file name cleanup once for every file that is published
+// until the next config change.
+func presenceOptionsFromConfig(cfg *Config) presenceOptions {
+	privacyRules, err := compilePrivacyRules(cfg.PrivacyRules)
+	if err != nil {
+		fmt.Println("Skipping privacy rules:", err)
+	}
+	redactions, err := compileRedactions(cfg.Redactions)
+	if err != nil {
+		fmt.Println("Skipping redactions:", err)
+	}
+	cleanup, err := compileFileNameCleanup(cfg.FileNameCleanup)
+	if err != nil {
+		fmt.Println("Skipping file name cleanup patterns:", err)
+	}
+	return presenceOptions{
+		PrivacyMode:    cfg.PrivacyMode,
+		CustomLabel:    sanitizeCustomLabel(cfg.CustomLabel),
+		ShowOtherFiles: cfg.ShowOtherFiles,
+		Assets:         cfg.Assets,
+		Buttons:        cfg.Buttons,
+		PrivacyRules:   privacyRules,
+		Redactions:     redactions,
+		Cleanup:        cleanup,
+
+		Templates:        cfg.Templates,
+		PrivacyTemplates: cfg.PrivacyTemplates,
+	}
+}
+
+// activityFromFilename builds the activity for a normalized Figma state.
+// privacy is options.privacyFor(figma.File), decided on the real name before
+// redaction; only the redacted name is published. A non-zero idleSince
+// builds the "Idle" variant, timed from then.
+func activityFromFilename(figma FigmaState, privacy privacyDecision, options presenceOptions, start, idleSince time.Time) discordipc.Activity {
+	if figma.File != "" {
+		figma.File = options.Redactions.redact(figma.File)
 	}
 
 	details := "Editing File"
-	state := filename
-	smallImage := "edit"
-	smallText := "Editing"
+	state := figma.File
 
-	if filename == "Browsing Files" {
+	switch figma.Mode {
+	case ModeHome:
 		details = "In Home"
 		state = "Browsing Files"
-		smallImage = "folder"
-		smallText = "Browsing"
+	case ModeDrafts:
+		details = "In Drafts"
+		state = "Browsing Files"
+	case ModeDevMode:
+		details = "Inspecting in Dev Mode"
+	case ModePrototype:
+		details = "Presenting Prototype"
+	case ModeFigJam:
+		details = "Brainstorming in FigJam"
+	}
+	small := options.Assets.forMode(figma.Mode)
+	idle := !idleSince.IsZero()
+	if idle {
+		details = "Idle"
+		small = options.Assets.Idle
+		start = idleSince
 	}
 
-	return client.Activity{
-		State:      state,
+	label := sanitizeCustomLabel(options.CustomLabel)
+	if privacy.HidesFile() {
+		label = privacy.Label
+		state = label
+	}
+
+	if options.ShowOtherFiles && state != "" && figma.OtherFiles > 0 {
+		state = fmt.Sprintf("%s (%s)", state, otherFilesLabel(figma.OtherFiles))
+	}
+
+	data := presenceTemplateData{
+		File:       figma.File,
+		Label:      label,
+		Mode:       figma.Mode.String(),
+		FileCount:  figma.FileCount,
+		OtherFiles: figma.OtherFiles,
+		Elapsed:    formatElapsed(time.Since(start)),
+		Idle:       idle,
 		Details:    details,
-		LargeImage: "largeimageid",
-		LargeText:  "Figma",
-		SmallImage: smallImage,
-		SmallText:  smallText,
-		Timestamps: &client.Timestamps{
-			Start: &start,
-		},
+		State:      state,
+		LargeText:  options.Assets.Large.Text,
+		SmallText:  small.Text,
+	}
+	templates := options.Templates
+	if privacy.HidesFile() {
+		templates = options.PrivacyTemplates
+		data.File = ""
+	}
+	details, state, largeText, smallText := templates.render(data)
+
+	return discordipc.Activity{
+		State:      fitActivityText(state),
+		Details:    fitActivityText(details),
+		LargeImage: options.Assets.Large.Image,
+		LargeText:  fitActivityText(largeText),
+		SmallImage: small.Image,
+		SmallText:  fitActivityText(smallText),
+		Start:      start,
+		Buttons:    renderButtons(options.Buttons, figma, privacy.HidesFile()),
 	}
 }
 
-func activitySignature(activity client.Activity) string {
-	return fmt.Sprintf("%s|%s|%s|%s", activity.Details, activity.State, activity.SmallImage, activity.SmallText)
+// normalizedState cleans up the file name as it comes from the title
+// source, before the privacy rules and redactions see it.
+func (o presenceOptions) normalizedState(figma FigmaState) FigmaState {
+	figma.File = o.Cleanup.normalize(figma.File)
+	return figma
+}
+
+// privacyFor applies the privacy rules and the Privacy Mode toggle to a file.
+func (o presenceOptions) privacyFor(file string) privacyDecision {
+	return decidePrivacy(o.PrivacyRules, file, o.PrivacyMode, o.CustomLabel)
+}
+
+// otherFilesLabel formats the "+N other files" suffix of the State line.
+func otherFilesLabel(count int) string {
+	if count == 1 {
+		return "+1 other file"
+	}
+	return fmt.Sprintf("+%d other files", count)
+}
+
+func minutesToDuration(minutes int) time.Duration {
+	if minutes <= 0 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func activitySignature(activity discordipc.Activity) string {
+	signature := fmt.Sprintf("%s|%s|%s|%s|%s|%s", activity.Details, activity.State, activity.LargeImage, activity.LargeText, activity.SmallImage, activity.SmallText)
+	for _, button := range activity.Buttons {
+		signature += "|" + button.Label + "|" + button.URL
+	}
+	return signature
+}
+
+func sanitizeClearPolicy(policy string) string {
+	if policy == clearPolicyDisconnect {
+		return clearPolicyDisconnect
+	}
+	return clearPolicyClear
 }
 
 func sanitizeCustomLabel(label string) string {
@@ -328,7 +917,7 @@ func sanitizeCustomLabel(label string) string {
 	return label
 }
 
-func pushLatestFilename(ch chan string, value string) {
+func pushLatestState(ch chan FigmaState, value FigmaState) {
 	select {
 	case ch <- value:
 	default:
@@ -0,0 +1,499 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"Figma-Discord-Rich-Presence/discordipc/ipctest"
+)
+
+// fakeClock is a settable clock for the components that take a now func.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2026, 3, 2, 9, 0, 0, 0, time.Local)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// newTestPoller returns a poller over a script, with a fake clock and its
+// log lines collected.
+func newTestPoller(script string) (*figmaPoller, *scriptedTitleSource, *fakeClock, *[]string) {
+	source := newScriptedTitleSource(parseTitleScript(script)...)
+	clock := newFakeClock()
+	var logs []string
+	poller := newFigmaPoller(source)
+	poller.now = clock.Now
+	poller.log = func(a ...any) { logs = append(logs, fmt.Sprint(a...)) }
+	return poller, source, clock, &logs
+}
+
+func TestPollerDebouncesEmptyPolls(t *testing.T) {
+	poller, source, _, _ := newTestPoller("Logo – Figma;;;Logo – Figma;;;;Home – Figma")
+
+	var published []FigmaState
+	for source.Reads() < 9 {
+		if figma, changed := poller.step(); changed {
+			published = append(published, figma)
+		}
+	}
+
+	// Two empty polls are a blip; the third in a row means Figma closed.
+	want := []FigmaState{
+		{Mode: ModeEditing, File: "Logo", RawTitle: "Logo – Figma", FileCount: 1},
+		{},
+		{Mode: ModeHome, RawTitle: "Home – Figma"},
+	}
+	if len(published) != len(want) {
+		t.Fatalf("published %+v, want %+v", published, want)
+	}
+	for i := range want {
+		if published[i] != want[i] {
+			t.Errorf("update %d = %+v, want %+v", i, published[i], want[i])
+		}
+	}
+}
+
+func TestPollerThrottlesReadErrors(t *testing.T) {
+	poller, _, clock, logs := newTestPoller("!boom;!boom;!boom;!boom;!other;Logo – Figma;!other")
+
+	steps := []struct {
+		advance time.Duration
+		logged  bool
+	}{
+		{0, true},                 // first error
+		{10 * time.Second, false}, // same error within 30s
+		{19 * time.Second, false},
+		{1 * time.Second, true},  // same error after 30s
+		{1 * time.Second, true},  // a different error
+		{1 * time.Second, false}, // a successful read
+		{1 * time.Second, true},  // the error again after a success
+	}
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		before := len(*logs)
+		poller.step()
+		if logged := len(*logs) > before; logged != step.logged {
+			t.Errorf("step %d logged = %t, want %t (logs %q)", i, logged, step.logged, *logs)
+		}
+	}
+}
+
+func TestPollerReprobesFailingSource(t *testing.T) {
+	// Errors short of the limit, a recovery, then a source that stays broken.
+	script := strings.Repeat("!boom;", titleSourceFailureLimit-1) + "Logo – Figma;!gone"
+	poller, broken, _, logs := newTestPoller(script)
+	replacement := newScriptedTitleSource(parseTitleScript("Brand – Figma")...)
+	selections := 0
+	poller.selectSource = func() (TitleSource, error) {
+		selections++
+		return replacement, nil
+	}
+
+	var published []string
+	for i := 0; i < 2*titleSourceFailureLimit+1; i++ {
+		if figma, changed := poller.step(); changed {
+			published = append(published, figma.File)
+		}
+	}
+
+	if selections != 1 {
+		t.Errorf("sources selected %d times, want once after %d failures in a row", selections, titleSourceFailureLimit)
+	}
+	if want := 2 * titleSourceFailureLimit; broken.Reads() != want {
+		t.Errorf("broken source read %d times, want %d", broken.Reads(), want)
+	}
+	if want := []string{"Logo", "Brand"}; !reflect.DeepEqual(published, want) {
+		t.Errorf("published %q, want %q", published, want)
+	}
+	if !strings.Contains(strings.Join(*logs, "\n"), "probing the title sources again") {
+		t.Errorf("re-probe not logged: %q", *logs)
+	}
+}
+
+// partialTitleSource is a scripted source that only sees part of the desktop.
+type partialTitleSource struct {
+	*scriptedTitleSource
+}
+
+func (partialTitleSource) Warning() string {
+	return "native windows are not visible"
+}
+
+func TestPollerLogsSourceWarning(t *testing.T) {
+	poller, source, _, logs := newTestPoller("Logo – Figma")
+	poller.source = nil
+	poller.selectSource = func() (TitleSource, error) {
+		return partialTitleSource{source}, nil
+	}
+
+	poller.step()
+	poller.step()
+	warnings := 0
+	for _, line := range *logs {
+		if strings.Contains(line, "native windows are not visible") {
+			warnings++
+		}
+	}
+	if warnings != 1 {
+		t.Errorf("warning logged %d times, want once when the source is chosen (logs %q)", warnings, *logs)
+	}
+}
+
+// managerHarness runs runRPCManager against a mock Discord, fed by a poller
+// over a scripted title source.
+type managerHarness struct {
+	t      *testing.T
+	server *ipctest.Server
+	source *scriptedTitleSource
+	events *UIEvents
+
+	dials  atomic.Int32 // Attempts to reach Discord, refused or not
+	refuse atomic.Bool  // Fail dials as if Discord were not running
+	dialMu sync.Mutex   // Held by a test to stall dials
+
+	mu          sync.Mutex
+	transitions []presenceTransition
+}
+
+func startManager(t *testing.T, cfg *Config, script string) *managerHarness {
+	t.Helper()
+
+	// Keep pause.json and friends out of the real config directory.
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("APPDATA", home)
+
+	server, err := ipctest.NewServer(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &managerHarness{
+		t:      t,
+		server: server,
+		source: newScriptedTitleSource(parseTitleScript(script)...),
+		events: NewUIEvents(),
+	}
+	dial := func(clientID string) (discordClient, error) {
+		h.dials.Add(1)
+		h.dialMu.Lock()
+		h.dialMu.Unlock()
+		if h.refuse.Load() {
+			return nil, errors.New("Discord is not running")
+		}
+		client, err := server.Dial(clientID)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
+	}
+
+	poller := newFigmaPoller(h.source)
+	poller.interval = 10 * time.Millisecond
+	poller.log = func(...any) {}
+
+	stop := make(chan struct{})
+	stateUpdates := make(chan FigmaState, 1)
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		poller.run(stateUpdates, stop)
+	}()
+	go runRPCManager(cfg.ClientID, dial, cfg, h.events, stateUpdates, make(chan time.Duration), stop, &wg, h.observe)
+
+	t.Cleanup(func() {
+		close(stop)
+		wg.Wait()
+		server.Close()
+	})
+	return h
+}
+
+func (h *managerHarness) observe(transition presenceTransition) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.transitions = append(h.transitions, transition)
+}
+
+// activity waits for the nth command and returns its activity, nil for a
+// cleared presence.
+func (h *managerHarness) activity(n int) map[string]any {
+	h.t.Helper()
+	commands, err := h.server.WaitForCommands(n, 6*time.Second)
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	activity, err := commands[n-1].Activity()
+	if err != nil {
+		h.t.Fatal(err)
+	}
+	return activity
+}
+
+// waitDials waits until Discord was dialed at least n times.
+func (h *managerHarness) waitDials(n int32) {
+	h.t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for h.dials.Load() < n {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("dialed %d times, want %d", h.dials.Load(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitConnections waits until n clients are connected to the mock Discord.
+func (h *managerHarness) waitConnections(n int) {
+	h.t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for h.server.Connections() != n {
+		if time.Now().After(deadline) {
+			h.t.Fatalf("%d connections, want %d", h.server.Connections(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// waitState waits until the presence machine reaches want.
+func (h *managerHarness) waitState(want presenceState) {
+	h.t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		h.mu.Lock()
+		reached := len(h.transitions) > 0 && h.transitions[len(h.transitions)-1].To == want
+		h.mu.Unlock()
+		if reached {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.t.Fatalf("presence never became %s; transitions %v", want, h.transitions)
+}
+
+func TestManagerPublishesActivity(t *testing.T) {
+	cfg := DefaultConfig()
+	h := startManager(t, cfg, "Logo – Figma")
+
+	activity := h.activity(1)
+	if activity["details"] != "Editing File" || activity["state"] != "Logo" {
+		t.Errorf("activity = %v", activity)
+	}
+	if handshakes := h.server.Handshakes(); len(handshakes) != 1 || handshakes[0] != cfg.ClientID {
+		t.Errorf("handshakes = %v, want [%s]", handshakes, cfg.ClientID)
+	}
+	h.waitState(presencePublishing)
+}
+
+func TestManagerClearsWhenFigmaCloses(t *testing.T) {
+	h := startManager(t, DefaultConfig(), "Logo – Figma")
+	h.activity(1)
+
+	h.source.Replace(parseTitleScript("")...)
+	if activity := h.activity(2); activity != nil {
+		t.Errorf("activity = %v, want a cleared presence", activity)
+	}
+	h.waitState(presenceConnectedIdle)
+	if handshakes := h.server.Handshakes(); len(handshakes) != 1 {
+		t.Errorf("the clear policy should keep the connection, got handshakes %v", handshakes)
+	}
+}
+
+func TestManagerReconnectReplaysActivity(t *testing.T) {
+	h := startManager(t, DefaultConfig(), "Logo – Figma")
+	h.activity(1)
+
+	// Discord restarts; the next update notices and reconnects.
+	h.server.DropConnections()
+	h.source.Replace(parseTitleScript("Brand – Figma")...)
+
+	if activity := h.activity(2); activity["state"] != "Brand" {
+		t.Errorf("activity after reconnect = %v", activity)
+	}
+	if handshakes := h.server.Handshakes(); len(handshakes) != 2 {
+		t.Errorf("handshakes = %v, want 2", handshakes)
+	}
+	h.waitState(presencePublishing)
+}
+
+func TestManagerCoalescesRateLimitedUpdates(t *testing.T) {
+	h := startManager(t, DefaultConfig(), "F1 – Figma;F2 – Figma;F3 – Figma;F4 – Figma;F5 – Figma;F6 – Figma;F7 – Figma;F8 – Figma")
+
+	// The burst is spent on the first five files...
+	h.activity(activityUpdateBurst)
+	time.Sleep(500 * time.Millisecond)
+	if n := len(h.server.Commands()); n != activityUpdateBurst {
+		t.Fatalf("sent %d updates, want %d before the bucket refills", n, activityUpdateBurst)
+	}
+
+	// ...and once a token is back only the latest file is sent.
+	if activity := h.activity(activityUpdateBurst + 1); activity["state"] != "F8" {
+		t.Errorf("coalesced activity = %v, want F8", activity)
+	}
+	time.Sleep(500 * time.Millisecond)
+	if n := len(h.server.Commands()); n != activityUpdateBurst+1 {
+		t.Errorf("sent %d updates, want %d", n, activityUpdateBurst+1)
+	}
+}
+
+func TestManagerRefusesPauseUntilFigmaClosesWhileClosed(t *testing.T) {
+	h := startManager(t, DefaultConfig(), "Logo – Figma")
+	h.activity(1)
+	h.source.Replace(parseTitleScript("")...)
+	h.activity(2)
+
+	// Nothing would end this pause, so it is refused.
+	h.events.Pause <- pauseState{UntilFigmaCloses: true}
+	for len(h.events.Pause) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+	h.source.Replace(parseTitleScript("Brand – Figma")...)
+	if activity := h.activity(3); activity["state"] != "Brand" {
+		t.Errorf("activity = %v, want Brand published", activity)
+	}
+
+	path, err := pausePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("pause.json should not exist, stat: %v", err)
+	}
+}
+
+func TestManagerSendsButtons(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Buttons = []PresenceButton{
+		{Label: "Find in Figma", URL: "https://www.figma.com/files/search?q={{urlquery .File}}"},
+		{Label: "Portfolio", URL: "https://example.com/{{.Mode}}", ShowInPrivacy: true},
+	}
+	cfg.PrivacyRules = []PrivacyRule{{Match: "Acme *", Action: privacyActionLabel}}
+	h := startManager(t, cfg, "Logo Sketch – Figma")
+
+	want := []any{
+		map[string]any{"label": "Find in Figma", "url": "https://www.figma.com/files/search?q=Logo+Sketch"},
+		map[string]any{"label": "Portfolio", "url": "https://example.com/editing"},
+	}
+	if buttons := h.activity(1)["buttons"]; !reflect.DeepEqual(buttons, want) {
+		t.Errorf("buttons = %v, want %v", buttons, want)
+	}
+
+	// A hidden file keeps only the buttons marked for privacy mode.
+	h.source.Replace(parseTitleScript("Acme Rebrand – Figma")...)
+	want = want[1:]
+	if buttons := h.activity(2)["buttons"]; !reflect.DeepEqual(buttons, want) {
+		t.Errorf("buttons for a hidden file = %v, want %v", buttons, want)
+	}
+}
+
+func TestManagerDisconnectClearsPresence(t *testing.T) {
+	h := startManager(t, DefaultConfig(), "Logo – Figma")
+	h.activity(1)
+
+	// Closing the connection is what removes the activity from the profile.
+	h.events.Disconnect <- struct{}{}
+	h.waitState(presenceDisabled)
+	h.waitConnections(0)
+
+	// New files are not published, and Discord is not dialed for them.
+	h.source.Replace(parseTitleScript("Brand – Figma")...)
+	time.Sleep(200 * time.Millisecond)
+	if n := h.dials.Load(); n != 1 {
+		t.Errorf("dialed %d times, want no dial while disconnected", n)
+	}
+	if commands := h.server.Commands(); len(commands) != 1 {
+		t.Errorf("sent %d commands, want only the first activity", len(commands))
+	}
+}
+
+func TestManagerReconnectPublishesCurrentActivity(t *testing.T) {
+	h := startManager(t, DefaultConfig(), "Logo – Figma")
+	h.activity(1)
+	h.events.Disconnect <- struct{}{}
+	h.waitState(presenceDisabled)
+	h.waitConnections(0)
+
+	// The file changes while disconnected; Reconnect publishes the new one.
+	reads := h.source.Reads()
+	h.source.Replace(parseTitleScript("Brand – Figma")...)
+	for h.source.Reads() < reads+2 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond)
+	h.events.Reconnect <- struct{}{}
+
+	if activity := h.activity(2); activity["state"] != "Brand" {
+		t.Errorf("activity after Reconnect = %v, want Brand", activity)
+	}
+	if handshakes := h.server.Handshakes(); len(handshakes) != 2 {
+		t.Errorf("handshakes = %v, want a new one for Reconnect", handshakes)
+	}
+	h.waitState(presencePublishing)
+}
+
+func TestManagerAppliesPrivacyModeToggle(t *testing.T) {
+	h := startManager(t, DefaultConfig(), "Logo – Figma")
+	if activity := h.activity(1); activity["state"] != "Logo" {
+		t.Fatalf("activity = %v", activity)
+	}
+
+	private := DefaultConfig()
+	private.PrivacyMode = true
+	private.CustomLabel = "Client work"
+	h.events.ConfigChanged <- private
+	if activity := h.activity(2); activity["state"] != "Client work" {
+		t.Errorf("activity in Privacy Mode = %v, want the label", activity)
+	}
+
+	h.events.ConfigChanged <- DefaultConfig()
+	if activity := h.activity(3); activity["state"] != "Logo" {
+		t.Errorf("activity after Privacy Mode = %v, want the file name", activity)
+	}
+	if handshakes := h.server.Handshakes(); len(handshakes) != 1 {
+		t.Errorf("handshakes = %v, want the connection kept", handshakes)
+	}
+}
+
+func TestManagerReportsHiddenFileWithoutDialing(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ClearPolicy = clearPolicyDisconnect
+	cfg.PrivacyRules = []PrivacyRule{{Match: "Acme *", Action: privacyActionHide}}
+	h := startManager(t, cfg, "Logo – Figma")
+	h.activity(1)
+
+	h.source.Replace(parseTitleScript("Acme Rebrand – Figma")...)
+	h.waitConnections(0)
+	deadline := time.After(3 * time.Second)
+	for status := (rpcStatus{}); status.Kind != statusHidden; {
+		select {
+		case status = <-h.events.Status:
+		case <-deadline:
+			t.Fatalf("status = %q, want %q", status.Text(), rpcStatus{Kind: statusHidden}.Text())
+		}
+	}
+	if n := h.dials.Load(); n != 1 {
+		t.Errorf("dialed %d times, want no dial for a hidden file", n)
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/rivo/uniseg"
+)
+
+// FileNameCleanup removes the noise Figma titles collect, such as
+// "(Copy)" or "v12 FINAL final", before the file name is published.
+type FileNameCleanup struct {
+	Enabled            bool     `json:"enabled"`
+	TrimSuffixes       []string `json:"trim_suffixes"`        // Regular expressions removed from the end of the name, case-insensitive, repeatedly
+	TrimPrefixes       []string `json:"trim_prefixes"`        // Regular expressions removed from the start, e.g. a workspace name
+	TrimLooseSuffixes  bool     `json:"trim_loose_suffixes"`  // Also trim a bare trailing "copy" or "final"; this cuts names like "Ad Copy"
+	StripTrailingEmoji bool     `json:"strip_trailing_emoji"` // Remove emoji at the end of the name
+}
+
+// DefaultFileNameCleanup trims "(Copy)" markers and trailing version tokens,
+// along with any "final" after them. Patterns that could eat real words are
+// left to TrimLooseSuffixes.
+func DefaultFileNameCleanup() FileNameCleanup {
+	return FileNameCleanup{
+		Enabled: true,
+		TrimSuffixes: []string{
+			`\(copy(?: \d+)?\)`,
+			`\bv\d+(?:\.\d+)*(?:[\s_-]+final)*`,
+		},
+	}
+}
+
+// looseSuffixPatterns are the suffixes TrimLooseSuffixes adds.
+var looseSuffixPatterns = []string{`\bcopy`, `\bfinal`}
+
+// zeroWidthReplacer drops invisible characters. The zero-width joiner is
+// kept since it is part of emoji like 👩‍💻.
+var zeroWidthReplacer = strings.NewReplacer(
+	"\u200b", "", // zero-width space
+	"\u200c", "", // zero-width non-joiner
+	"\u2060", "", // word joiner
+	"\ufeff", "", // byte order mark
+	"\u00ad", "", // soft hyphen
+)
+
+// fileNameCleaner is a FileNameCleanup with its patterns compiled, built
+// once per config change rather than for every file.
+type fileNameCleaner struct {
+	enabled            bool
+	stripTrailingEmoji bool
+	suffixes           []*regexp.Regexp
+	prefixes           []*regexp.Regexp
+}
+
+// compileFileNameCleanup compiles the cleanup. Patterns that don't compile
+// are left out and reported in the error.
+func compileFileNameCleanup(c FileNameCleanup) (fileNameCleaner, error) {
+	suffixPatterns := c.TrimSuffixes
+	if c.TrimLooseSuffixes {
+		suffixPatterns = append(append([]string(nil), suffixPatterns...), looseSuffixPatterns...)
+	}
+	suffixes, suffixErr := compileAffixes(suffixPatterns, `(?i)(?:%s)[\s\-–_.,]*$`)
+	prefixes, prefixErr := compileAffixes(c.TrimPrefixes, `(?i)^[\s]*(?:%s)[\s\-–_/:|]*`)
+	return fileNameCleaner{
+		enabled:            c.Enabled,
+		stripTrailingEmoji: c.StripTrailingEmoji,
+		suffixes:           suffixes,
+		prefixes:           prefixes,
+	}, errors.Join(suffixErr, prefixErr)
+}
+
+// normalize cleans up a file name. Names that would come out empty are
+// returned with only whitespace and dashes fixed.
+func (c fileNameCleaner) normalize(name string) string {
+	if !c.enabled || name == "" {
+		return name
+	}
+
+	name = normalizeSpacing(name)
+	cleaned := name
+	for {
+		before := cleaned
+		if c.stripTrailingEmoji {
+			cleaned = trimTrailingEmoji(cleaned)
+		}
+		for _, pattern := range c.suffixes {
+			cleaned = strings.TrimSpace(pattern.ReplaceAllLiteralString(cleaned, ""))
+		}
+		for _, pattern := range c.prefixes {
+			cleaned = strings.TrimSpace(pattern.ReplaceAllLiteralString(cleaned, ""))
+		}
+		if cleaned == before {
+			break
+		}
+	}
+
+	cleaned = strings.Trim(cleaned, " -–_")
+	if cleaned == "" {
+		return name
+	}
+	return cleaned
+}
+
+// normalizeSpacing strips zero-width characters, turns NBSPs and other
+// spaces into plain ones, collapses runs of whitespace and unifies the
+// Unicode dashes: an en dash between spaces, a hyphen otherwise. ASCII
+// hyphens are the user's own and are left alone, spaced or not.
+func normalizeSpacing(name string) string {
+	name = zeroWidthReplacer.Replace(name)
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r) || unicode.Is(unicode.Zs, r):
+			return ' '
+		case r == '\u2212' || (unicode.Is(unicode.Pd, r) && r != '-'):
+			return '–'
+		}
+		return r
+	}, name)
+	runes := []rune(strings.Join(strings.Fields(name), " "))
+	for i, r := range runes {
+		spaced := i > 0 && runes[i-1] == ' ' && i+1 < len(runes) && runes[i+1] == ' '
+		if r == '–' && !spaced {
+			runes[i] = '-'
+		}
+	}
+	return string(runes)
+}
+
+func compileAffixes(patterns []string, format string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	var errs []error
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(fmt.Sprintf(format, pattern))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("file name pattern %q: %w", pattern, err))
+			continue
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, errors.Join(errs...)
+}
+
+// trimTrailingEmoji removes emoji graphemes, and the spaces before them,
+// from the end of the name.
+func trimTrailingEmoji(name string) string {
+	var clusters []string
+	graphemes := uniseg.NewGraphemes(name)
+	for graphemes.Next() {
+		clusters = append(clusters, graphemes.Str())
+	}
+
+	end := len(clusters)
+	for end > 0 {
+		first, _ := utf8.DecodeRuneInString(clusters[end-1])
+		if !unicode.Is(unicode.So, first) && !unicode.IsSpace(first) {
+			break
+		}
+		end--
+	}
+	return strings.Join(clusters[:end], "")
+}
+
+// sanitize drops patterns that don't compile.
+func (c *FileNameCleanup) sanitize() {
+	c.TrimSuffixes = validPatterns(c.TrimSuffixes)
+	c.TrimPrefixes = validPatterns(c.TrimPrefixes)
+}
+
+func validPatterns(patterns []string) []string {
+	var valid []string
+	for _, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err == nil {
+			valid = append(valid, pattern)
+		}
+	}
+	return valid
+}
+
+func (c FileNameCleanup) validate() error {
+	for _, pattern := range c.TrimSuffixes {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("file_name_cleanup.trim_suffixes: %q: %w", pattern, err)
+		}
+	}
+	for _, pattern := range c.TrimPrefixes {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("file_name_cleanup.trim_prefixes: %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// fitActivityText makes a text acceptable to Discord: longer texts are cut
+// at a grapheme boundary with an ellipsis, and one-character texts are
+// padded with an invisible blank. Empty texts are left out by Discord and
+// stay empty.
+func fitActivityText(text string) string {
+	n := utf8.RuneCountInString(text)
+	if n == 0 {
+		return text
+	}
+	if n < minActivityTextRunes {
+		return text + strings.Repeat("\u2800", minActivityTextRunes-n)
+	}
+	if n <= maxActivityTextRunes {
+		return text
+	}
+
+	var b strings.Builder
+	count := 0
+	graphemes := uniseg.NewGraphemes(text)
+	for graphemes.Next() {
+		cluster := graphemes.Str()
+		runes := utf8.RuneCountInString(cluster)
+		if count+runes > maxActivityTextRunes-1 {
+			break
+		}
+		b.WriteString(cluster)
+		count += runes
+	}
+	return strings.TrimRightFunc(b.String(), unicode.IsSpace) + "…"
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFileNameCleanupDefaults(t *testing.T) {
+	cleanup := mustCompileCleanup(t, DefaultFileNameCleanup())
+	tests := []struct {
+		name, want string
+	}{
+		{"Landing (Copy)", "Landing"},
+		{"Landing (copy 2)", "Landing"},
+		{"Landing v12", "Landing"},
+		{"Landing v1.2.3", "Landing"},
+		{"Landing v12 FINAL final", "Landing"},
+		{"Landing – v2_final (Copy)", "Landing"},
+		{"Ad Copy", "Ad Copy"},
+		{"Marketing Copy", "Marketing Copy"},
+		{"Champions League Final", "Champions League Final"},
+		{"Icons 🎨 v3", "Icons 🎨"},
+		{"Icons 🎨", "Icons 🎨"},
+		{"Final", "Final"},
+		{"v2", "v2"},
+		{"Landing\u00a0 Page\u200b", "Landing Page"},
+		{"Q3 - Roadmap", "Q3 - Roadmap"},
+		{"Q3 — Roadmap", "Q3 – Roadmap"},
+		{"Q3\u00a0\u2212 Roadmap", "Q3 – Roadmap"},
+		{"Sign‑up—Flow", "Sign-up-Flow"},
+		{"Sign-up Flow", "Sign-up Flow"},
+	}
+	for _, tt := range tests {
+		if got := cleanup.normalize(tt.name); got != tt.want {
+			t.Errorf("normalize(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFileNameCleanupLooseSuffixes(t *testing.T) {
+	config := DefaultFileNameCleanup()
+	config.TrimLooseSuffixes = true
+	config.StripTrailingEmoji = true
+	cleanup := mustCompileCleanup(t, config)
+	tests := []struct {
+		name, want string
+	}{
+		{"Landing copy", "Landing"},
+		{"Landing final FINAL", "Landing"},
+		{"Icons 🎨 v3", "Icons"},
+		{"Landing 👩‍💻", "Landing"},
+		{"Final", "Final"},
+	}
+	for _, tt := range tests {
+		if got := cleanup.normalize(tt.name); got != tt.want {
+			t.Errorf("normalize(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFileNameCleanupSkipsBadPatterns(t *testing.T) {
+	config := DefaultFileNameCleanup()
+	config.TrimSuffixes = []string{"(", "draft"}
+	config.TrimPrefixes = []string{"Acme"}
+
+	cleanup, err := compileFileNameCleanup(config)
+	if err == nil || !strings.Contains(err.Error(), `"("`) {
+		t.Errorf("error = %v, want the bad pattern reported", err)
+	}
+	if got := cleanup.normalize("Acme – Landing draft"); got != "Landing" {
+		t.Errorf("normalize = %q, want the good patterns applied", got)
+	}
+}
+
+func mustCompileCleanup(t *testing.T, config FileNameCleanup) fileNameCleaner {
+	t.Helper()
+	cleanup, err := compileFileNameCleanup(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cleanup
+}
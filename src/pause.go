@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// pauseCheckInterval is how often the RPC manager looks for a pause set by
+// `figma-rpc pause` from another process.
+const pauseCheckInterval = 2 * time.Second
+
+// pauseState is a break from broadcasting the user asked for. It is kept in
+// pause.json next to config.json, so it survives restarts and the CLI can
+// pause a running app. The zero value means not paused.
+type pauseState struct {
+	Until            time.Time `json:"until,omitzero"`               // Resume at this time
+	UntilFigmaCloses bool      `json:"until_figma_closes,omitempty"` // Resume once no Figma window is left
+}
+
+// Active reports whether the pause is still in effect at now.
+func (p pauseState) Active(now time.Time) bool {
+	return p.UntilFigmaCloses || now.Before(p.Until)
+}
+
+// Equal reports whether two pauses end the same way. Times read back from
+// pause.json lose their monotonic reading, so == does not do.
+func (p pauseState) Equal(other pauseState) bool {
+	return p.Until.Equal(other.Until) && p.UntilFigmaCloses == other.UntilFigmaCloses
+}
+
+// Describe explains when the pause ends, for logs and the CLI.
+func (p pauseState) Describe() string {
+	if p.UntilFigmaCloses {
+		return "until Figma closes"
+	}
+	return "until " + p.Until.Format("Mon 15:04")
+}
+
+// parsePause turns a pause argument into a pause starting at now: a
+// duration such as 15m or 1h, "tomorrow" for the next local midnight, or
+// "figma-closed".
+func parsePause(arg string, now time.Time) (pauseState, error) {
+	switch strings.ToLower(strings.TrimSpace(arg)) {
+	case "tomorrow":
+		year, month, day := now.Date()
+		return pauseState{Until: time.Date(year, month, day+1, 0, 0, 0, 0, now.Location())}, nil
+	case "figma-closed":
+		return pauseState{UntilFigmaCloses: true}, nil
+	}
+
+	d, err := time.ParseDuration(arg)
+	if err != nil || d <= 0 {
+		return pauseState{}, fmt.Errorf("%q is not a duration like 15m or 1h, \"tomorrow\" or \"figma-closed\"", arg)
+	}
+	return pauseState{Until: now.Add(d)}, nil
+}
+
+// pausePath returns the full path to the pause file.
+func pausePath() (string, error) {
+	dir, err := configDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "pause.json"), nil
+}
+
+// loadPause reads the pause file and its modification time. A missing file
+// is no pause.
+func loadPause() (pauseState, time.Time, error) {
+	path, err := pausePath()
+	if err != nil {
+		return pauseState{}, time.Time{}, err
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return pauseState{}, time.Time{}, nil
+	}
+	if err != nil {
+		return pauseState{}, time.Time{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return pauseState{}, time.Time{}, err
+	}
+
+	var pause pauseState
+	if err := json.Unmarshal(data, &pause); err != nil {
+		return pauseState{}, info.ModTime(), fmt.Errorf("could not parse pause: %w", err)
+	}
+	return pause, info.ModTime(), nil
+}
+
+// savePause writes the pause file, or removes it for the zero pause, and
+// returns the new modification time.
+func savePause(pause pauseState) (time.Time, error) {
+	path, err := pausePath()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if pause.Equal(pauseState{}) {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return time.Time{}, fmt.Errorf("could not remove pause: %w", err)
+		}
+		return time.Time{}, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return time.Time{}, fmt.Errorf("could not create config directory: %w", err)
+	}
+	data, err := json.MarshalIndent(pause, "", "  ")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not serialize pause: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return time.Time{}, fmt.Errorf("could not write pause: %w", err)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// formatPauseRemaining renders the countdown of a timed pause, rounded up
+// so a fresh 15 minute pause shows 15m.
+func formatPauseRemaining(d time.Duration) string {
+	if d < time.Minute {
+		return "<1m"
+	}
+	return formatElapsed((d + time.Minute - 1).Truncate(time.Minute))
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePause(t *testing.T) {
+	now := time.Date(2026, 3, 2, 21, 30, 0, 0, time.Local)
+	tests := []struct {
+		arg     string
+		want    pauseState
+		wantErr bool
+	}{
+		{"15m", pauseState{Until: now.Add(15 * time.Minute)}, false},
+		{"1h", pauseState{Until: now.Add(time.Hour)}, false},
+		{"1h30m", pauseState{Until: now.Add(90 * time.Minute)}, false},
+		{"tomorrow", pauseState{Until: time.Date(2026, 3, 3, 0, 0, 0, 0, time.Local)}, false},
+		{"Tomorrow", pauseState{Until: time.Date(2026, 3, 3, 0, 0, 0, 0, time.Local)}, false},
+		{"figma-closed", pauseState{UntilFigmaCloses: true}, false},
+		{" FIGMA-CLOSED ", pauseState{UntilFigmaCloses: true}, false},
+		{"0s", pauseState{}, true},
+		{"-5m", pauseState{}, true},
+		{"15", pauseState{}, true},
+		{"soon", pauseState{}, true},
+		{"", pauseState{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parsePause(tt.arg, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePause(%q) error = %v, want error %t", tt.arg, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parsePause(%q) = %+v, want %+v", tt.arg, got, tt.want)
+		}
+	}
+}
+
+func TestPauseRoundTrip(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("APPDATA", home)
+
+	for _, pause := range []pauseState{
+		{Until: time.Now().Add(time.Hour).Truncate(time.Second)},
+		{UntilFigmaCloses: true},
+	} {
+		saved, err := savePause(pause)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded, modTime, err := loadPause()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !loaded.Equal(pause) || !modTime.Equal(saved) {
+			t.Errorf("loaded %+v at %s, want %+v at %s", loaded, modTime, pause, saved)
+		}
+	}
+
+	// The last pause has no end time, which is left out rather than
+	// written as year 1.
+	path, err := pausePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(path); err != nil || strings.Contains(string(data), "until\"") {
+		t.Errorf("pause.json = %s, %v; want no until", data, err)
+	}
+
+	// Resuming removes the file, which loads as no pause.
+	if _, err := savePause(pauseState{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("pause.json should be removed, stat: %v", err)
+	}
+	if loaded, _, err := loadPause(); err != nil || !loaded.Equal(pauseState{}) {
+		t.Errorf("loaded %+v, %v after resuming, want no pause", loaded, err)
+	}
+}
+
+func TestExpiredPauseFileIsIgnored(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("APPDATA", home)
+
+	// Written by `figma-rpc pause 15m` an hour ago.
+	modTime, err := savePause(pauseState{Until: time.Now().Add(-45 * time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := &rpcManagerState{}
+	checkPauseFile(state)
+	if !state.pause.Equal(pauseState{}) {
+		t.Errorf("pause = %+v, want the expired pause ignored", state.pause)
+	}
+	if !state.pauseModTime.Equal(modTime) {
+		t.Errorf("pause.json was not read")
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// presenceState is what the RPC manager is doing with the Discord presence.
+type presenceState int
+
+const (
+	presenceDisabled      presenceState = iota // RPC turned off by the user
+	presenceWaiting                            // Enabled without a connection to Discord
+	presenceConnectedIdle                      // Connected with nothing to show, e.g. Figma is closed
+	presencePublishing                         // An activity is shown on the profile
+	presencePaused                             // Presence withheld while the user is away or has paused it
+)
+
+func (s presenceState) String() string {
+	switch s {
+	case presenceDisabled:
+		return "Disabled"
+	case presenceWaiting:
+		return "Waiting for Discord"
+	case presenceConnectedIdle:
+		return "Connected (idle)"
+	case presencePublishing:
+		return "Publishing"
+	case presencePaused:
+		return "Paused"
+	default:
+		return fmt.Sprintf("presenceState(%d)", int(s))
+	}
+}
+
+// presenceEvent is something that happened to the presence.
+type presenceEvent int
+
+const (
+	presenceEnable         presenceEvent = iota // The user turned RPC on
+	presenceDisable                             // The user turned RPC off
+	presenceConnecting                          // There is something to publish but no connection
+	presenceConnected                           // A connection to Discord was opened
+	presenceConnectionLost                      // Discord went away while connected
+	presencePublish                             // An activity was sent
+	presenceClear                               // There is nothing to show
+	presencePause                               // The user is away or paused, and presence is withheld
+	presenceDisconnected                        // There is nothing to show and no connection is kept
+)
+
+func (e presenceEvent) String() string {
+	switch e {
+	case presenceEnable:
+		return "enable"
+	case presenceDisable:
+		return "disable"
+	case presenceConnecting:
+		return "connecting"
+	case presenceConnected:
+		return "connected"
+	case presenceConnectionLost:
+		return "connection lost"
+	case presencePublish:
+		return "publish"
+	case presenceClear:
+		return "clear"
+	case presencePause:
+		return "pause"
+	case presenceDisconnected:
+		return "disconnected"
+	default:
+		return fmt.Sprintf("presenceEvent(%d)", int(e))
+	}
+}
+
+// presenceTransitions is the transition table. Events missing for a state
+// are not valid there and leave the state unchanged. Only presenceConnected
+// leads to presenceConnectedIdle from a state without a connection.
+var presenceTransitions = map[presenceState]map[presenceEvent]presenceState{
+	presenceDisabled: {
+		presenceEnable: presenceWaiting,
+	},
+	presenceConnectedIdle: {
+		presenceDisable:        presenceDisabled,
+		presenceConnecting:     presenceWaiting,
+		presenceConnectionLost: presenceWaiting,
+		presenceDisconnected:   presenceWaiting,
+		presencePublish:        presencePublishing,
+		presencePause:          presencePaused,
+	},
+	presenceWaiting: {
+		presenceDisable:   presenceDisabled,
+		presenceConnected: presenceConnectedIdle,
+		presencePause:     presencePaused,
+	},
+	presencePublishing: {
+		presenceDisable:        presenceDisabled,
+		presenceConnectionLost: presenceWaiting,
+		presenceDisconnected:   presenceWaiting,
+		presencePublish:        presencePublishing,
+		presenceClear:          presenceConnectedIdle,
+		presencePause:          presencePaused,
+	},
+	presencePaused: {
+		presenceDisable:        presenceDisabled,
+		presenceConnecting:     presenceWaiting,
+		presenceConnectionLost: presenceWaiting,
+		presenceDisconnected:   presenceWaiting,
+		presencePublish:        presencePublishing,
+		presenceClear:          presenceConnectedIdle,
+	},
+}
+
+// presenceTransition is one step of the state machine, with a human readable
+// reason such as "Figma closed".
+type presenceTransition struct {
+	From   presenceState
+	To     presenceState
+	Event  presenceEvent
+	Reason string
+	At     time.Time
+}
+
+func (t presenceTransition) String() string {
+	if t.Reason == "" {
+		return fmt.Sprintf("%s -> %s (%s)", t.From, t.To, t.Event)
+	}
+	return fmt.Sprintf("%s -> %s (%s: %s)", t.From, t.To, t.Event, t.Reason)
+}
+
+// presenceMachine holds the presence state and tells subscribers about every
+// transition. It is owned by the RPC manager goroutine; subscribers run on it
+// and must not block.
+type presenceMachine struct {
+	state       presenceState
+	now         func() time.Time
+	subscribers []func(presenceTransition)
+}
+
+func newPresenceMachine(enabled bool, now func() time.Time) *presenceMachine {
+	m := &presenceMachine{state: presenceDisabled, now: now}
+	if enabled {
+		m.state = presenceWaiting
+	}
+	return m
+}
+
+// State returns the current state.
+func (m *presenceMachine) State() presenceState {
+	return m.state
+}
+
+// Subscribe registers fn to be called with every transition.
+func (m *presenceMachine) Subscribe(fn func(presenceTransition)) {
+	m.subscribers = append(m.subscribers, fn)
+}
+
+// Fire applies an event and reports whether the current state accepts it.
+// Accepted events are emitted even when the state stays the same.
+func (m *presenceMachine) Fire(event presenceEvent, reason string) bool {
+	next, ok := presenceTransitions[m.state][event]
+	if !ok {
+		return false
+	}
+
+	transition := presenceTransition{From: m.state, To: next, Event: event, Reason: reason, At: m.now()}
+	m.state = next
+	for _, fn := range m.subscribers {
+		fn(transition)
+	}
+	return true
+}
+
+// logPresenceTransition is the default subscriber: it prints every change.
+func logPresenceTransition(t presenceTransition) {
+	fmt.Println("Presence:", t)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPresenceTransitions(t *testing.T) {
+	tests := []struct {
+		name   string
+		start  bool
+		events []presenceEvent
+		want   presenceState
+	}{
+		{"starts waiting", true, nil, presenceWaiting},
+		{"starts disabled", false, nil, presenceDisabled},
+		{"enable waits for a connection", false, []presenceEvent{presenceEnable}, presenceWaiting},
+		{"clear without a connection keeps waiting", true, []presenceEvent{presenceClear}, presenceWaiting},
+		{"connect", true, []presenceEvent{presenceConnected}, presenceConnectedIdle},
+		{"publish", true, []presenceEvent{presenceConnected, presencePublish}, presencePublishing},
+		{"clear keeps the connection", true, []presenceEvent{presenceConnected, presencePublish, presenceClear}, presenceConnectedIdle},
+		{"clear with disconnect", true, []presenceEvent{presenceConnected, presencePublish, presenceDisconnected}, presenceWaiting},
+		{"pause and clear", true, []presenceEvent{presenceConnected, presencePause, presenceClear}, presenceConnectedIdle},
+		{"pause and disconnect", true, []presenceEvent{presencePause, presenceDisconnected}, presenceWaiting},
+		{"connection lost", true, []presenceEvent{presenceConnected, presencePublish, presenceConnectionLost}, presenceWaiting},
+		{"disable", true, []presenceEvent{presenceConnected, presenceDisable}, presenceDisabled},
+	}
+	for _, tt := range tests {
+		m := newPresenceMachine(tt.start, time.Now)
+		for _, event := range tt.events {
+			m.Fire(event, "")
+		}
+		if got := m.State(); got != tt.want {
+			t.Errorf("%s: state = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestStatusWithoutConnection(t *testing.T) {
+	tests := []struct {
+		name       string
+		events     []presenceEvent
+		figma      FigmaState
+		connecting bool
+		want       rpcStatusKind
+	}{
+		{"Figma closed", nil, FigmaState{}, false, statusFigmaNotDetected},
+		{"file open", []presenceEvent{presenceConnecting}, FigmaState{Mode: ModeEditing, File: "Logo"}, true, statusWaiting},
+		{"hidden file", []presenceEvent{presenceClear}, FigmaState{Mode: ModeEditing, File: "Secret"}, false, statusHidden},
+		{"dropped after clearing", []presenceEvent{presenceConnected, presenceDisconnected}, FigmaState{Mode: ModeEditing, File: "Secret"}, false, statusHidden},
+		{"connection lost", []presenceEvent{presenceConnected, presencePublish, presenceConnectionLost}, FigmaState{Mode: ModeEditing, File: "Logo"}, true, statusWaiting},
+	}
+	for _, tt := range tests {
+		connector := newDiscordConnector("client", func(string) (discordClient, error) {
+			return nil, errors.New("Discord is not running")
+		})
+		connector.after = func(time.Duration) <-chan time.Time { return nil }
+		if tt.connecting {
+			connector.Start()
+		}
+		state := &rpcManagerState{presence: newPresenceMachine(true, time.Now), connector: connector, currentState: tt.figma}
+		for _, event := range tt.events {
+			state.presence.Fire(event, "")
+		}
+		if got := currentStatus(state).Kind; got != tt.want {
+			t.Errorf("%s: status = %s, want %s", tt.name, currentStatus(state).Text(), rpcStatus{Kind: tt.want}.Text())
+		}
+		connector.Stop()
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Actions a privacy rule can take for a matching file.
+const (
+	privacyActionShow        = "show"         // Show the file name
+	privacyActionLabel       = "label"        // Hide the file name behind the replacement label
+	privacyActionCustomLabel = "custom_label" // Hide the file name behind the rule's own label
+	privacyActionHide        = "hide"         // Publish no presence at all while the file is open
+)
+
+var privacyActions = []string{privacyActionShow, privacyActionLabel, privacyActionCustomLabel, privacyActionHide}
+
+// PrivacyRule decides how files whose name matches are shown. Rules are
+// checked in order and the first match wins; files no rule matches follow
+// the Privacy Mode toggle.
+type PrivacyRule struct {
+	Match  string `json:"match"`           // Glob with * and ?, case-insensitive; a regular expression when Regex is set
+	Regex  bool   `json:"regex,omitempty"` // Treat Match as a Go regular expression, matched anywhere in the name
+	Action string `json:"action"`          // "show", "label", "custom_label" or "hide"
+	Label  string `json:"label,omitempty"` // Shown instead of the file name for "custom_label"
+}
+
+// privacyDecision is the outcome of the rules for one file.
+type privacyDecision struct {
+	Action string
+	Label  string // Replaces the file name unless Action is "show" or "hide"
+	Rule   int    // Index of the matching rule, -1 when the Privacy Mode toggle decided
+}
+
+// HidesFile reports whether the file name must not reach Discord.
+func (d privacyDecision) HidesFile() bool {
+	return d.Action != privacyActionShow
+}
+
+// privacyRuleSet is the privacy rules with their patterns compiled, built
+// once per config change rather than for every file.
+type privacyRuleSet struct {
+	rules    []PrivacyRule
+	patterns []*regexp.Regexp // nil for a rule that doesn't compile
+}
+
+// compilePrivacyRules compiles the rules. Rules that don't compile are
+// skipped when deciding and reported in the error.
+func compilePrivacyRules(rules []PrivacyRule) (privacyRuleSet, error) {
+	set := privacyRuleSet{rules: rules, patterns: make([]*regexp.Regexp, len(rules))}
+	var errs []error
+	for i, rule := range rules {
+		pattern, err := rule.compile()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %d: %w", i+1, err))
+			continue
+		}
+		set.patterns[i] = pattern
+	}
+	return set, errors.Join(errs...)
+}
+
+// decidePrivacy applies the rules to a file name. Without a file (Home,
+// Drafts) or without a matching rule, privacyMode decides between showing
+// the name and the replacement label.
+func decidePrivacy(rules privacyRuleSet, file string, privacyMode bool, customLabel string) privacyDecision {
+	if file != "" {
+		for i, rule := range rules.rules {
+			if rules.patterns[i] == nil || !rules.patterns[i].MatchString(file) {
+				continue
+			}
+
+			decision := privacyDecision{Action: rule.Action, Rule: i}
+			switch rule.Action {
+			case privacyActionLabel:
+				decision.Label = sanitizeCustomLabel(customLabel)
+			case privacyActionCustomLabel:
+				decision.Label = strings.TrimSpace(rule.Label)
+			}
+			return decision
+		}
+	}
+
+	if privacyMode {
+		return privacyDecision{Action: privacyActionLabel, Label: sanitizeCustomLabel(customLabel), Rule: -1}
+	}
+	return privacyDecision{Action: privacyActionShow, Rule: -1}
+}
+
+// compile turns the rule into a regular expression. Globs must match the
+// whole name.
+func (r PrivacyRule) compile() (*regexp.Regexp, error) {
+	if r.Regex {
+		return regexp.Compile(r.Match)
+	}
+
+	var b strings.Builder
+	b.WriteString("(?i)^")
+	for _, c := range r.Match {
+		switch c {
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+func (r PrivacyRule) validate() error {
+	if strings.TrimSpace(r.Match) == "" {
+		return fmt.Errorf("match is empty")
+	}
+	if _, err := r.compile(); err != nil {
+		return fmt.Errorf("match %q: %w", r.Match, err)
+	}
+
+	switch r.Action {
+	case privacyActionShow, privacyActionLabel, privacyActionHide:
+	case privacyActionCustomLabel:
+		n := utf8.RuneCountInString(strings.TrimSpace(r.Label))
+		if n < minActivityTextRunes || n > maxActivityTextRunes {
+			return fmt.Errorf("label must be %d to %d characters, got %d", minActivityTextRunes, maxActivityTextRunes, n)
+		}
+	default:
+		return fmt.Errorf("action %q is not one of %s", r.Action, strings.Join(privacyActions, ", "))
+	}
+	return nil
+}
+
+// validatePrivacyRules reports the first invalid rule.
+func validatePrivacyRules(rules []PrivacyRule) error {
+	for i, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return fmt.Errorf("privacy_rules[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// sanitizePrivacyRules drops invalid rules, keeping the order of the rest.
+func sanitizePrivacyRules(rules []PrivacyRule) []PrivacyRule {
+	var valid []PrivacyRule
+	for _, rule := range rules {
+		if rule.validate() == nil {
+			valid = append(valid, rule)
+		}
+	}
+	return valid
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDecidePrivacyPrecedence(t *testing.T) {
+	rules := []PrivacyRule{
+		{Match: "Acme *", Action: privacyActionHide},
+		{Match: "*NDA*", Action: privacyActionCustomLabel, Label: "Client work"},
+		{Match: `^Draft \d+$`, Regex: true, Action: privacyActionLabel},
+		{Match: "*", Action: privacyActionShow},
+	}
+	tests := []struct {
+		name        string
+		rules       []PrivacyRule
+		file        string
+		privacyMode bool
+		action      string
+		label       string
+		rule        int
+	}{
+		{"first match wins", rules, "Acme NDA deck", false, privacyActionHide, "", 0},
+		{"globs ignore case", rules, "acme rebrand", false, privacyActionHide, "", 0},
+		{"globs match the whole name", rules, "The Acme Rebrand", false, privacyActionShow, "", 3},
+		{"custom label", rules, "Partner NDA", false, privacyActionCustomLabel, "Client work", 1},
+		{"regex", rules, "Draft 12", false, privacyActionLabel, "Working on a project", 2},
+		{"a show rule beats Privacy Mode", rules, "Logo", true, privacyActionShow, "", 3},
+		{"no match follows Privacy Mode off", rules[:3], "Logo", false, privacyActionShow, "", -1},
+		{"no match follows Privacy Mode on", rules[:3], "Logo", true, privacyActionLabel, "Working on a project", -1},
+		{"rules are skipped outside files", rules, "", true, privacyActionLabel, "Working on a project", -1},
+		{"an invalid rule is skipped", []PrivacyRule{{Match: "(", Regex: true, Action: privacyActionHide}}, "(", false, privacyActionShow, "", -1},
+	}
+	for _, tt := range tests {
+		set, _ := compilePrivacyRules(tt.rules)
+		got := decidePrivacy(set, tt.file, tt.privacyMode, "")
+		want := privacyDecision{Action: tt.action, Label: tt.label, Rule: tt.rule}
+		if got != want {
+			t.Errorf("%s: decidePrivacy(%q) = %+v, want %+v", tt.name, tt.file, got, want)
+		}
+	}
+}
+
+func TestCompilePrivacyRulesReportsInvalidRules(t *testing.T) {
+	set, err := compilePrivacyRules([]PrivacyRule{
+		{Match: "Logo", Action: privacyActionHide},
+		{Match: "(", Regex: true, Action: privacyActionHide},
+	})
+	if err == nil {
+		t.Error("no error for a rule that doesn't compile")
+	}
+	if decision := decidePrivacy(set, "Logo", false, ""); decision.Rule != 0 {
+		t.Errorf("valid rule not applied: %+v", decision)
+	}
+}
+
+func TestPresenceOptionsCompareEqual(t *testing.T) {
+	// A config change is detected by comparing options, so compiling the
+	// same rules twice must give equal options.
+	cfg := DefaultConfig()
+	cfg.PrivacyRules = []PrivacyRule{{Match: "Acme *", Action: privacyActionHide}, {Match: `\d+`, Regex: true, Action: privacyActionLabel}}
+	if !reflect.DeepEqual(presenceOptionsFromConfig(cfg), presenceOptionsFromConfig(cfg)) {
+		t.Error("options from the same config differ")
+	}
+
+	changed := DefaultConfig()
+	changed.PrivacyRules = []PrivacyRule{{Match: "Acme *", Action: privacyActionShow}}
+	if reflect.DeepEqual(presenceOptionsFromConfig(cfg), presenceOptionsFromConfig(changed)) {
+		t.Error("options from different rules compare equal")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"math"
+	"time"
+)
+
+// Discord accepts about 5 activity updates per 20 seconds and silently drops
+// the rest, which can leave a stale file on the profile.
+const (
+	activityUpdateBurst  = 5
+	activityUpdateWindow = 20 * time.Second
+)
+
+// tokenBucket allows capacity events at once, refilled evenly over window.
+// The clock is a field so it can be driven by a fake in tests.
+type tokenBucket struct {
+	capacity float64
+	interval time.Duration // Time to earn back one token
+	tokens   float64
+	last     time.Time
+	now      func() time.Time
+}
+
+func newTokenBucket(capacity int, window time.Duration, now func() time.Time) *tokenBucket {
+	return &tokenBucket{
+		capacity: float64(capacity),
+		interval: window / time.Duration(capacity),
+		tokens:   float64(capacity),
+		last:     now(),
+		now:      now,
+	}
+}
+
+// take spends a token and returns 0, or returns how long until one is
+// available without spending anything.
+func (b *tokenBucket) take() time.Duration {
+	now := b.now()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+float64(elapsed)/float64(b.interval))
+	}
+	b.last = now
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration(math.Ceil((1 - b.tokens) * float64(b.interval)))
+}
+
+// activityLimiter keeps SET_ACTIVITY within Discord's rate limit. Updates
+// over budget are coalesced: Ready fires once when a token is back, and the
+// caller then sends whatever is current at that point.
+type activityLimiter struct {
+	bucket  *tokenBucket
+	after   func(time.Duration) <-chan time.Time
+	pending <-chan time.Time // nil unless an update is waiting for budget
+}
+
+func newActivityLimiter(now func() time.Time, after func(time.Duration) <-chan time.Time) *activityLimiter {
+	return &activityLimiter{
+		bucket: newTokenBucket(activityUpdateBurst, activityUpdateWindow, now),
+		after:  after,
+	}
+}
+
+// Allow reports whether an update may be sent now and spends a token if so.
+// Otherwise it arms Ready; further calls while waiting are coalesced into it.
+func (l *activityLimiter) Allow() bool {
+	if l.pending != nil {
+		return false
+	}
+	wait := l.bucket.take()
+	if wait == 0 {
+		return true
+	}
+	l.pending = l.after(wait)
+	return false
+}
+
+// Ready fires when a held back update may be retried. It is nil, and so
+// never fires, while nothing is waiting.
+func (l *activityLimiter) Ready() <-chan time.Time {
+	return l.pending
+}
+
+// Fired must be called after receiving from Ready, before calling Allow.
+func (l *activityLimiter) Fired() {
+	l.pending = nil
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTokenBucketRefill(t *testing.T) {
+	clock := newFakeClock()
+	bucket := newTokenBucket(5, 20*time.Second, clock.Now)
+
+	for i := 0; i < 5; i++ {
+		if wait := bucket.take(); wait != 0 {
+			t.Fatalf("take %d waits %s, want the burst to be free", i+1, wait)
+		}
+	}
+
+	steps := []struct {
+		advance time.Duration
+		want    time.Duration
+	}{
+		{0, 4 * time.Second}, // empty, one token takes 4s
+		{1 * time.Second, 3 * time.Second},
+		{3 * time.Second, 0}, // refilled one token and spent it
+		{0, 4 * time.Second},
+		{time.Minute, 0}, // refills up to capacity only
+		{0, 0},
+		{0, 0},
+		{0, 0},
+		{0, 0},
+		{0, 4 * time.Second},
+	}
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		if wait := bucket.take(); wait != step.want {
+			t.Errorf("step %d: take waits %s, want %s", i, wait, step.want)
+		}
+	}
+}
+
+func TestActivityLimiterCoalesces(t *testing.T) {
+	clock := newFakeClock()
+	var armed []time.Duration
+	var ready chan time.Time
+	limiter := newActivityLimiter(clock.Now, func(d time.Duration) <-chan time.Time {
+		armed = append(armed, d)
+		ready = make(chan time.Time, 1)
+		return ready
+	})
+
+	for i := 0; i < activityUpdateBurst; i++ {
+		if !limiter.Allow() {
+			t.Fatalf("update %d held back within the burst", i+1)
+		}
+	}
+	if limiter.Ready() != nil {
+		t.Fatal("Ready armed while within budget")
+	}
+
+	// Everything over budget waits on a single timer.
+	for i := 0; i < 3; i++ {
+		if limiter.Allow() {
+			t.Fatalf("update %d over budget was allowed", i+1)
+		}
+	}
+	if len(armed) != 1 || armed[0] != activityUpdateWindow/activityUpdateBurst {
+		t.Fatalf("timers armed %v, want one for %s", armed, activityUpdateWindow/activityUpdateBurst)
+	}
+
+	clock.Advance(armed[0])
+	ready <- clock.Now()
+	<-limiter.Ready()
+	limiter.Fired()
+	if !limiter.Allow() {
+		t.Error("the coalesced update was held back after the refill")
+	}
+	if limiter.Allow() {
+		t.Error("a second update was allowed with the bucket empty")
+	}
+	if len(armed) != 2 {
+		t.Errorf("timers armed %v, want a new one for the next update", armed)
+	}
+}
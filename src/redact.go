@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rivo/uniseg"
+)
+
+// redactionMask replaces redacted text unless a rule has its own token. It
+// has a fixed length so the mask doesn't reveal how long the secret was.
+const redactionMask = "███"
+
+// Built-in redactions, for RedactionRule.Builtin.
+const (
+	redactBuiltinEmail    = "email"    // Email addresses
+	redactBuiltinTicket   = "ticket"   // Ticket IDs like ACME-1234
+	redactBuiltinBrackets = "brackets" // Anything inside [square brackets]
+)
+
+type builtinRedaction struct {
+	pattern *regexp.Regexp
+	mask    string
+}
+
+var builtinRedactions = map[string]builtinRedaction{
+	redactBuiltinEmail:    {regexp.MustCompile(`[\p{L}\p{N}._%+-]+@[\p{L}\p{N}-]+(?:\.[\p{L}\p{N}-]+)*\.\p{L}{2,}`), redactionMask},
+	redactBuiltinTicket:   {regexp.MustCompile(`\b[A-Z][A-Z0-9]{1,9}-[0-9]+\b`), redactionMask},
+	redactBuiltinBrackets: {regexp.MustCompile(`\[[^\[\]]+\]`), "[" + redactionMask + "]"},
+}
+
+// RedactionRule masks part of a file name before it is published, for
+// names where only some words are sensitive. Set either Builtin or Pattern.
+type RedactionRule struct {
+	Builtin string `json:"builtin,omitempty"` // "email", "ticket" or "brackets"
+	Pattern string `json:"pattern,omitempty"` // Go regular expression
+	Replace string `json:"replace,omitempty"` // Token replacing each match; empty uses ███
+}
+
+func (r RedactionRule) compile() (*regexp.Regexp, string, error) {
+	if r.Builtin != "" {
+		builtin, ok := builtinRedactions[r.Builtin]
+		if !ok {
+			return nil, "", fmt.Errorf("unknown builtin %q, use %s, %s or %s", r.Builtin, redactBuiltinEmail, redactBuiltinTicket, redactBuiltinBrackets)
+		}
+		if r.Replace != "" {
+			return builtin.pattern, r.Replace, nil
+		}
+		return builtin.pattern, builtin.mask, nil
+	}
+
+	if r.Pattern == "" {
+		return nil, "", fmt.Errorf("set either builtin or pattern")
+	}
+	pattern, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return nil, "", fmt.Errorf("pattern %q: %w", r.Pattern, err)
+	}
+	if pattern.MatchString("") {
+		return nil, "", fmt.Errorf("pattern %q matches empty text", r.Pattern)
+	}
+	if r.Replace != "" {
+		return pattern, r.Replace, nil
+	}
+	return pattern, redactionMask, nil
+}
+
+// redactionSet is the redactions with their patterns compiled, built once
+// per config change rather than for every file.
+type redactionSet struct {
+	patterns []*regexp.Regexp
+	masks    []string
+}
+
+// compileRedactions compiles the rules in order. Rules that don't compile
+// are left out and reported in the error.
+func compileRedactions(rules []RedactionRule) (redactionSet, error) {
+	var set redactionSet
+	var errs []error
+	for i, rule := range rules {
+		pattern, mask, err := rule.compile()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("redaction %d: %w", i+1, err))
+			continue
+		}
+		set.patterns = append(set.patterns, pattern)
+		set.masks = append(set.masks, mask)
+	}
+	return set, errors.Join(errs...)
+}
+
+// redact applies the redactions in order. Every match is widened to whole
+// graphemes, so an emoji sequence or a letter with its accents is masked
+// whole and the result is always valid UTF-8.
+func (s redactionSet) redact(name string) string {
+	for i, pattern := range s.patterns {
+		matches := pattern.FindAllStringIndex(name, -1)
+		if matches == nil {
+			continue
+		}
+
+		boundaries := graphemeBoundaries(name)
+		var b strings.Builder
+		last := 0
+		for _, match := range matches {
+			start, end := widenToGraphemes(boundaries, match[0], match[1])
+			if start < last {
+				// Shares a grapheme with the previous match, whose mask
+				// covers both.
+				last = max(last, end)
+				continue
+			}
+			b.WriteString(name[last:start])
+			b.WriteString(s.masks[i])
+			last = end
+		}
+		b.WriteString(name[last:])
+		name = b.String()
+	}
+	return strings.TrimSpace(name)
+}
+
+// graphemeBoundaries returns the byte offsets where graphemes of text start,
+// and len(text).
+func graphemeBoundaries(text string) []int {
+	var boundaries []int
+	graphemes := uniseg.NewGraphemes(text)
+	for graphemes.Next() {
+		start, _ := graphemes.Positions()
+		boundaries = append(boundaries, start)
+	}
+	return append(boundaries, len(text))
+}
+
+// widenToGraphemes moves start back and end forward to the nearest grapheme
+// boundaries.
+func widenToGraphemes(boundaries []int, start, end int) (int, int) {
+	widenedStart, widenedEnd := start, end
+	for _, boundary := range boundaries {
+		if boundary <= start {
+			widenedStart = boundary
+		}
+		if boundary >= end {
+			widenedEnd = boundary
+			break
+		}
+	}
+	return widenedStart, widenedEnd
+}
+
+// validateRedactions reports the first invalid rule.
+func validateRedactions(rules []RedactionRule) error {
+	for i, rule := range rules {
+		if _, _, err := rule.compile(); err != nil {
+			return fmt.Errorf("redactions[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// sanitizeRedactions drops invalid rules, keeping the order of the rest.
+func sanitizeRedactions(rules []RedactionRule) []RedactionRule {
+	var valid []RedactionRule
+	for _, rule := range rules {
+		if _, _, err := rule.compile(); err == nil {
+			valid = append(valid, rule)
+		}
+	}
+	return valid
+}
+
+// hasBuiltinRedaction reports whether a built-in is in the list.
+func hasBuiltinRedaction(rules []RedactionRule, builtin string) bool {
+	for _, rule := range rules {
+		if rule.Builtin == builtin {
+			return true
+		}
+	}
+	return false
+}
+
+// withBuiltinRedaction adds or removes a built-in, keeping the other rules.
+// Added built-ins go first so they run before custom patterns.
+func withBuiltinRedaction(rules []RedactionRule, builtin string, enabled bool) []RedactionRule {
+	var out []RedactionRule
+	if enabled {
+		out = append(out, RedactionRule{Builtin: builtin})
+	}
+	for _, rule := range rules {
+		if rule.Builtin != builtin {
+			out = append(out, rule)
+		}
+	}
+	return out
+}
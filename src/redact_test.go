@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRedact(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []RedactionRule
+		file  string
+		want  string
+	}{
+		{"email", []RedactionRule{{Builtin: redactBuiltinEmail}}, "Notes for jane.doe@acme.com", "Notes for ███"},
+		{"ticket", []RedactionRule{{Builtin: redactBuiltinTicket}}, "ACME-1234 Checkout", "███ Checkout"},
+		{"brackets", []RedactionRule{{Builtin: redactBuiltinBrackets}}, "[Client] Rebrand", "[███] Rebrand"},
+		{"own token", []RedactionRule{{Pattern: `Acme`, Replace: "Client"}}, "Acme Rebrand", "Client Rebrand"},
+		{"rules apply in order", []RedactionRule{{Builtin: redactBuiltinBrackets}, {Pattern: `███`, Replace: "x"}}, "[NDA] Deck", "[x] Deck"},
+
+		// Matches are widened to whole graphemes.
+		{"combining mark", []RedactionRule{{Pattern: `Cafe`}}, "Cafe\u0301 Menu", "███ Menu"},
+		{"emoji sequence", []RedactionRule{{Pattern: "👩"}}, "Team 👩\u200d💻 Sync", "Team ███ Sync"},
+		{"skin tone", []RedactionRule{{Pattern: "👍"}}, "Review 👍🏽", "Review ███"},
+		{"flag", []RedactionRule{{Pattern: "\U0001F1E9"}}, "Launch 🇩🇪", "Launch ███"},
+		{"matches in one grapheme", []RedactionRule{{Pattern: "[👩💻]"}}, "👩\u200d💻 Notes", "███ Notes"},
+		{"CJK", []RedactionRule{{Pattern: `株式会社\S+`}}, "株式会社アクメ 新規LP", "███ 新規LP"},
+		{"CJK email", []RedactionRule{{Builtin: redactBuiltinEmail}}, "連絡 山田@例え.jp", "連絡 ███"},
+	}
+	for _, tt := range tests {
+		set, err := compileRedactions(tt.rules)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := set.redact(tt.file)
+		if got != tt.want {
+			t.Errorf("%s: redact(%q) = %q, want %q", tt.name, tt.file, got, tt.want)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("%s: redact(%q) is not valid UTF-8", tt.name, tt.file)
+		}
+	}
+}
+
+func TestCompileRedactionsSkipsInvalidRules(t *testing.T) {
+	set, err := compileRedactions([]RedactionRule{{Pattern: "("}, {Builtin: "phone"}, {Pattern: "x*"}, {Pattern: "Acme"}})
+	if err == nil {
+		t.Error("no error for rules that don't compile")
+	}
+	if got := set.redact("Acme Rebrand"); got != "███ Rebrand" {
+		t.Errorf("redact = %q, want the valid rule applied", got)
+	}
+}
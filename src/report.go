@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// journalTotal is the time spent on one file or on one day.
+type journalTotal struct {
+	Name    string  `json:"name"`
+	Seconds float64 `json:"seconds"`
+}
+
+// journalReport summarizes the journal since a point in time.
+type journalReport struct {
+	Since time.Time      `json:"since"`
+	Files []journalTotal `json:"files"`
+	Days  []journalTotal `json:"days"`
+}
+
+// runReportCommand implements `figma-rpc report`. It returns the process exit code.
+func runReportCommand(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("report", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	since := flags.String("since", "7d", "how far back to report: a duration like 7d, 2w or 36h, or a date (2006-01-02)")
+	format := flags.String("format", "table", "output format: table, csv or json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	now := time.Now()
+	sinceTime, err := parseSince(*since, now)
+	if err != nil {
+		fmt.Fprintln(stderr, "Invalid --since:", err)
+		return 2
+	}
+
+	path, err := journalPath()
+	if err != nil {
+		fmt.Fprintln(stderr, "Could not locate journal:", err)
+		return 1
+	}
+	entries, err := readJournal(path)
+	if err != nil {
+		fmt.Fprintln(stderr, "Warning:", err)
+	}
+
+	report := buildJournalReport(entries, sinceTime, now)
+
+	switch *format {
+	case "table":
+		writeReportTable(stdout, report)
+	case "csv":
+		err = writeReportCSV(stdout, report)
+	case "json":
+		err = writeReportJSON(stdout, report)
+	default:
+		fmt.Fprintf(stderr, "Unknown --format %q (use table, csv or json)\n", *format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "Could not write report:", err)
+		return 1
+	}
+	return 0
+}
+
+// parseSince accepts "7d", "2w", any time.ParseDuration value, or a local date.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("empty value")
+	}
+
+	if date, err := time.ParseInLocation("2006-01-02", value, now.Location()); err == nil {
+		return date, nil
+	}
+
+	unit := value[len(value)-1]
+	if unit == 'd' || unit == 'w' {
+		count, err := strconv.Atoi(value[:len(value)-1])
+		if err != nil || count < 0 {
+			return time.Time{}, fmt.Errorf("%q is not a whole number of days or weeks", value)
+		}
+		if unit == 'w' {
+			count *= 7
+		}
+		return now.AddDate(0, 0, -count), nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return now.Add(-duration), nil
+}
+
+// buildJournalReport clips entries to [since, now] and totals them per file
+// and per local calendar day. Intervals crossing midnight are split.
+func buildJournalReport(entries []journalEntry, since, now time.Time) journalReport {
+	files := make(map[string]time.Duration)
+	days := make(map[string]time.Duration)
+
+	for _, entry := range entries {
+		start, end := entry.Start.Local(), entry.End.Local()
+		if start.Before(since) {
+			start = since
+		}
+		if end.After(now) {
+			end = now
+		}
+		if !end.After(start) {
+			continue
+		}
+
+		name := entry.File
+		if name == "" {
+			name = "(" + entry.Mode + ")"
+		}
+		files[name] += end.Sub(start)
+
+		for dayStart := start; dayStart.Before(end); {
+			y, m, d := dayStart.Date()
+			nextDay := time.Date(y, m, d+1, 0, 0, 0, 0, dayStart.Location())
+			dayEnd := end
+			if nextDay.Before(end) {
+				dayEnd = nextDay
+			}
+			days[dayStart.Format("2006-01-02")] += dayEnd.Sub(dayStart)
+			dayStart = dayEnd
+		}
+	}
+
+	report := journalReport{Since: since, Files: sortedTotals(files), Days: sortedTotals(days)}
+	// Days read best in calendar order.
+	sort.Slice(report.Days, func(i, j int) bool { return report.Days[i].Name < report.Days[j].Name })
+	return report
+}
+
+// sortedTotals orders totals by time spent, largest first.
+func sortedTotals(totals map[string]time.Duration) []journalTotal {
+	out := make([]journalTotal, 0, len(totals))
+	for name, total := range totals {
+		out = append(out, journalTotal{Name: name, Seconds: total.Round(time.Second).Seconds()})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Seconds != out[j].Seconds {
+			return out[i].Seconds > out[j].Seconds
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out
+}
+
+func writeReportTable(w io.Writer, report journalReport) {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintf(tw, "Since %s\n\n", report.Since.Format("2006-01-02 15:04"))
+
+	fmt.Fprintln(tw, "FILE\tTOTAL")
+	for _, total := range report.Files {
+		fmt.Fprintf(tw, "%s\t%s\n", total.Name, formatTotal(total.Seconds))
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "DAY\tTOTAL")
+	for _, total := range report.Days {
+		fmt.Fprintf(tw, "%s\t%s\n", total.Name, formatTotal(total.Seconds))
+	}
+	tw.Flush()
+}
+
+func writeReportCSV(w io.Writer, report journalReport) error {
+	cw := csv.NewWriter(w)
+	rows := [][]string{{"kind", "name", "seconds"}}
+	for _, total := range report.Files {
+		rows = append(rows, []string{"file", total.Name, strconv.FormatFloat(total.Seconds, 'f', 0, 64)})
+	}
+	for _, total := range report.Days {
+		rows = append(rows, []string{"day", total.Name, strconv.FormatFloat(total.Seconds, 'f', 0, 64)})
+	}
+	if err := cw.WriteAll(rows); err != nil {
+		return err
+	}
+	return cw.Error()
+}
+
+func writeReportJSON(w io.Writer, report journalReport) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+func formatTotal(seconds float64) string {
+	d := time.Duration(seconds) * time.Second
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2026, 3, 9, 15, 30, 0, 0, time.Local)
+	tests := []struct {
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"7d", time.Date(2026, 3, 2, 15, 30, 0, 0, time.Local), false},
+		{"0d", now, false},
+		{"2w", time.Date(2026, 2, 23, 15, 30, 0, 0, time.Local), false},
+		{"36h", now.Add(-36 * time.Hour), false},
+		{"90m", now.Add(-90 * time.Minute), false},
+		{"2026-03-01", time.Date(2026, 3, 1, 0, 0, 0, 0, time.Local), false},
+		{" 7d ", time.Date(2026, 3, 2, 15, 30, 0, 0, time.Local), false},
+		{"", time.Time{}, true},
+		{"d", time.Time{}, true},
+		{"-3d", time.Time{}, true},
+		{"1.5w", time.Time{}, true},
+		{"yesterday", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSince(tt.value, now)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseSince(%q) error = %v, want error %t", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("parseSince(%q) = %s, want %s", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestBuildJournalReport(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, 3, day, hour, minute, 0, 0, time.Local)
+	}
+	entries := []journalEntry{
+		{File: "Logo", Mode: "editing", Start: at(1, 10, 0), End: at(1, 11, 0)},  // before since
+		{File: "Logo", Mode: "editing", Start: at(2, 23, 30), End: at(3, 0, 45)}, // crosses midnight
+		{File: "Brand", Mode: "editing", Start: at(3, 9, 0), End: at(3, 9, 30)},
+		{Mode: "home", Start: at(3, 9, 30), End: at(3, 9, 40)},
+		{File: "Brand", Mode: "editing", Start: at(3, 17, 50), End: at(3, 18, 20)}, // runs past now
+	}
+
+	report := buildJournalReport(entries, at(2, 0, 0), at(3, 18, 0))
+
+	wantFiles := []journalTotal{
+		{Name: "Logo", Seconds: 75 * 60},
+		{Name: "Brand", Seconds: 40 * 60},
+		{Name: "(home)", Seconds: 10 * 60},
+	}
+	if !reflect.DeepEqual(report.Files, wantFiles) {
+		t.Errorf("files = %+v, want %+v", report.Files, wantFiles)
+	}
+	wantDays := []journalTotal{
+		{Name: "2026-03-02", Seconds: 30 * 60},
+		{Name: "2026-03-03", Seconds: 95 * 60},
+	}
+	if !reflect.DeepEqual(report.Days, wantDays) {
+		t.Errorf("days = %+v, want %+v", report.Days, wantDays)
+	}
+}
+
+func TestReportEncoders(t *testing.T) {
+	report := journalReport{
+		Since: time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC),
+		Files: []journalTotal{{Name: "Logo, v2", Seconds: 4500}, {Name: "(home)", Seconds: 600}},
+		Days:  []journalTotal{{Name: "2026-03-02", Seconds: 5100}},
+	}
+
+	var csvOut bytes.Buffer
+	if err := writeReportCSV(&csvOut, report); err != nil {
+		t.Fatal(err)
+	}
+	wantCSV := "kind,name,seconds\nfile,\"Logo, v2\",4500\nfile,(home),600\nday,2026-03-02,5100\n"
+	if csvOut.String() != wantCSV {
+		t.Errorf("CSV = %q, want %q", csvOut.String(), wantCSV)
+	}
+
+	var jsonOut bytes.Buffer
+	if err := writeReportJSON(&jsonOut, report); err != nil {
+		t.Fatal(err)
+	}
+	var decoded journalReport
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+		t.Fatalf("JSON %q: %v", jsonOut.String(), err)
+	}
+	if !decoded.Since.Equal(report.Since) || !reflect.DeepEqual(decoded.Files, report.Files) || !reflect.DeepEqual(decoded.Days, report.Days) {
+		t.Errorf("JSON round trip = %+v, want %+v", decoded, report)
+	}
+	var fields map[string]any
+	json.Unmarshal(jsonOut.Bytes(), &fields)
+	for _, key := range []string{"since", "files", "days"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("JSON lacks %q: %s", key, jsonOut.String())
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// FigmaWindow is a single Figma window seen by a TitleSource.
+type FigmaWindow struct {
+	Title   string // Raw window title
+	Focused bool   // Whether this is the foreground window of the desktop
+}
+
+// TitleResult is one observation of the open Figma windows.
+type TitleResult struct {
+	Windows []FigmaWindow // Every Figma window found, in enumeration order
+}
+
+// TitleSource reads Figma window titles from the OS. Each platform registers
+// one or more sources from an init function; the poller uses whichever is
+// selected at startup.
+type TitleSource interface {
+	// Name identifies the source in logs.
+	Name() string
+	// Probe reports whether the source works in the current session.
+	Probe() error
+	// Read returns the current Figma windows. An empty result means Figma is closed.
+	Read() (TitleResult, error)
+}
+
+// titleSourceWarner is implemented by sources that can tell when they only
+// see part of the desktop, such as X11 in a Wayland session.
+type titleSourceWarner interface {
+	// Warning explains what the source cannot see, or returns "".
+	Warning() string
+}
+
+// titleSourceWarning returns the source's warning, if it has one.
+func titleSourceWarning(source TitleSource) string {
+	if warner, ok := source.(titleSourceWarner); ok {
+		return warner.Warning()
+	}
+	return ""
+}
+
+type registeredTitleSource struct {
+	priority int
+	source   TitleSource
+}
+
+var (
+	titleSourcesMu sync.Mutex
+	titleSources   []registeredTitleSource
+)
+
+// RegisterTitleSource adds a source to the registry. Sources with a lower
+// priority are probed first.
+func RegisterTitleSource(priority int, source TitleSource) {
+	titleSourcesMu.Lock()
+	defer titleSourcesMu.Unlock()
+
+	titleSources = append(titleSources, registeredTitleSource{priority: priority, source: source})
+	sort.SliceStable(titleSources, func(i, j int) bool {
+		return titleSources[i].priority < titleSources[j].priority
+	})
+}
+
+// SelectTitleSource returns the first registered source whose Probe succeeds.
+func SelectTitleSource() (TitleSource, error) {
+	titleSourcesMu.Lock()
+	candidates := append([]registeredTitleSource(nil), titleSources...)
+	titleSourcesMu.Unlock()
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no window title sources registered for this platform")
+	}
+
+	var errs []string
+	for _, candidate := range candidates {
+		if err := candidate.source.Probe(); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", candidate.source.Name(), err))
+			continue
+		}
+		return candidate.source, nil
+	}
+
+	return nil, fmt.Errorf("no window title source available (%s)", strings.Join(errs, "; "))
+}
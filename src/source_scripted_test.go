@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// scriptedStep is one canned answer from a scriptedTitleSource.
+type scriptedStep struct {
+	Result TitleResult
+	Err    error
+}
+
+// scriptedTitleSource replays a fixed list of steps, one per Read, and then
+// keeps repeating the last one. It lets the poller be driven deterministically.
+type scriptedTitleSource struct {
+	mu    sync.Mutex
+	steps []scriptedStep
+	next  int
+	reads int
+}
+
+func newScriptedTitleSource(steps ...scriptedStep) *scriptedTitleSource {
+	return &scriptedTitleSource{steps: steps}
+}
+
+// parseTitleScript builds steps from a compact script. Steps are separated
+// by ";", window titles within a step by "|", a leading "*" marks the focused
+// window, and a step of the form "!message" is returned as a read error. An
+// empty step means Figma is closed.
+//
+//	"Design System – Figma;;;Home – Figma|*Logo – Figma;!boom"
+func parseTitleScript(script string) []scriptedStep {
+	rawSteps := strings.Split(script, ";")
+	steps := make([]scriptedStep, 0, len(rawSteps))
+	for _, raw := range rawSteps {
+		raw = strings.TrimSpace(raw)
+		if strings.HasPrefix(raw, "!") {
+			steps = append(steps, scriptedStep{Err: errors.New(strings.TrimPrefix(raw, "!"))})
+			continue
+		}
+
+		var windows []FigmaWindow
+		for _, title := range strings.Split(raw, "|") {
+			title = strings.TrimSpace(title)
+			focused := strings.HasPrefix(title, "*")
+			if title = strings.TrimPrefix(title, "*"); title != "" {
+				windows = append(windows, FigmaWindow{Title: title, Focused: focused})
+			}
+		}
+		steps = append(steps, scriptedStep{Result: TitleResult{Windows: windows}})
+	}
+	return steps
+}
+
+func (s *scriptedTitleSource) Name() string {
+	return "scripted"
+}
+
+func (s *scriptedTitleSource) Probe() error {
+	return nil
+}
+
+func (s *scriptedTitleSource) Read() (TitleResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reads++
+	if len(s.steps) == 0 {
+		return TitleResult{}, nil
+	}
+
+	step := s.steps[s.next]
+	if s.next < len(s.steps)-1 {
+		s.next++
+	}
+	return step.Result, step.Err
+}
+
+// Replace swaps in a new script, starting from its first step.
+func (s *scriptedTitleSource) Replace(steps ...scriptedStep) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.steps = steps
+	s.next = 0
+}
+
+// Reads returns how many times Read has been called.
+func (s *scriptedTitleSource) Reads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reads
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// rpcStatusKind is the headline of what the RPC manager is doing.
+type rpcStatusKind int
+
+const (
+	statusDisabled rpcStatusKind = iota
+	statusWaiting
+	statusConnected
+	statusFigmaNotDetected
+	statusHidden // Figma is open but nothing is published for it, so Discord isn't dialed
+	statusPublishing
+	statusPaused
+	statusError
+)
+
+// rpcStatus is what the settings window and tray menu show. The RPC manager
+// sends it to the UI whenever it changes.
+type rpcStatus struct {
+	Kind    rpcStatusKind
+	User    string // Discord username, once connected
+	File    string // What the presence shows, while publishing
+	Message string // Error text
+
+	PausedUntil      time.Time // End of a timed pause, for the countdown
+	UntilFigmaCloses bool      // Paused until Figma closes
+}
+
+// Text is the one-line description of the status.
+func (s rpcStatus) Text() string {
+	switch s.Kind {
+	case statusWaiting:
+		return "Waiting for Discord"
+	case statusConnected:
+		if s.User == "" {
+			return "Connected"
+		}
+		return "Connected as " + s.User
+	case statusFigmaNotDetected:
+		return "Figma not detected"
+	case statusHidden:
+		return "Hidden for this file"
+	case statusPublishing:
+		return fmt.Sprintf("Publishing %q", shortenStatusText(s.File))
+	case statusPaused:
+		switch {
+		case s.UntilFigmaCloses:
+			return "Paused until Figma closes"
+		case !s.PausedUntil.IsZero():
+			return fmt.Sprintf("Paused, %s left", formatPauseRemaining(time.Until(s.PausedUntil)))
+		}
+		return "Paused"
+	case statusError:
+		return "Error: " + shortenStatusText(s.Message)
+	default:
+		return "Disconnected"
+	}
+}
+
+// maxStatusTextRunes keeps the status short enough for the status card and
+// the tray menu.
+const maxStatusTextRunes = 40
+
+func shortenStatusText(text string) string {
+	runes := []rune(text)
+	if len(runes) <= maxStatusTextRunes {
+		return text
+	}
+	return string(runes[:maxStatusTextRunes-1]) + "…"
+}
+
+// currentStatus derives the status from the manager state. A rejected
+// activity is reported until the next successful publish.
+func currentStatus(state *rpcManagerState) rpcStatus {
+	presence := state.presence.State()
+	if presence != presenceDisabled && state.lastError != "" {
+		return rpcStatus{Kind: statusError, Message: state.lastError}
+	}
+
+	switch presence {
+	case presenceWaiting:
+		if state.currentState.Mode == ModeClosed {
+			return rpcStatus{Kind: statusFigmaNotDetected}
+		}
+		// Without a running attempt nothing is waiting for Discord; a
+		// privacy rule hides the file.
+		if !state.connector.Connecting() {
+			return rpcStatus{Kind: statusHidden}
+		}
+		return rpcStatus{Kind: statusWaiting}
+	case presencePublishing:
+		return rpcStatus{Kind: statusPublishing, File: state.publishedLabel}
+	case presencePaused:
+		if state.pause.Active(time.Now()) {
+			return rpcStatus{Kind: statusPaused, PausedUntil: state.pause.Until, UntilFigmaCloses: state.pause.UntilFigmaCloses}
+		}
+		return rpcStatus{Kind: statusPaused}
+	case presenceConnectedIdle:
+		if state.currentState.Mode == ModeClosed {
+			return rpcStatus{Kind: statusFigmaNotDetected}
+		}
+		status := rpcStatus{Kind: statusConnected}
+		if state.conn != nil {
+			status.User = state.conn.User().Username
+		}
+		return status
+	default:
+		return rpcStatus{Kind: statusDisabled}
+	}
+}
+
+// reportStatus sends the current status to the UI if it changed.
+func reportStatus(state *rpcManagerState, ch chan rpcStatus) {
+	status := currentStatus(state)
+	if status == state.lastStatus {
+		return
+	}
+	state.lastStatus = status
+	pushLatestStatus(ch, status)
+}
+
+func pushLatestStatus(ch chan rpcStatus, value rpcStatus) {
+	select {
+	case ch <- value:
+	default:
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- value
+	}
+}
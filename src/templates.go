@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// Discord rejects activity texts outside these lengths.
+const (
+	minActivityTextRunes = 2
+	maxActivityTextRunes = 128
+)
+
+// PresenceTemplates are text/templates for the lines of the activity. An
+// empty template keeps the built-in text. See presenceTemplateData for the
+// available variables.
+type PresenceTemplates struct {
+	Details   string `json:"details"`
+	State     string `json:"state"`
+	LargeText string `json:"large_text"`
+	SmallText string `json:"small_text"`
+}
+
+// presenceTemplateData is what templates can refer to.
+type presenceTemplateData struct {
+	File       string // File name; empty in privacy mode and outside files
+	Label      string // The privacy replacement label
+	Mode       string // "editing", "home", "drafts", "prototype", "dev mode" or "figjam"
+	FileCount  int    // Open Figma files
+	OtherFiles int    // Open files besides the current one
+	Elapsed    string // Time on the elapsed timer, e.g. "1h 05m"; refreshed whenever the presence is re-sent
+	Idle       bool   // The user is away; Details is then "Idle" and Elapsed counts the idle time
+
+	// The built-in texts, for templates that only add to them.
+	Details   string
+	State     string
+	LargeText string
+	SmallText string
+}
+
+// templateField pairs a template with the built-in text it replaces.
+type templateField struct {
+	name     string
+	template string
+	builtin  string
+}
+
+func (t PresenceTemplates) fields(data presenceTemplateData) []templateField {
+	return []templateField{
+		{"details", t.Details, data.Details},
+		{"state", t.State, data.State},
+		{"large_text", t.LargeText, data.LargeText},
+		{"small_text", t.SmallText, data.SmallText},
+	}
+}
+
+// render fills in all four lines. A template that fails or renders empty
+// falls back to the built-in text, with a log line. Lengths are not checked
+// here: the caller fits every text to Discord's limits, so a long file name
+// is shortened rather than dropping the template.
+func (t PresenceTemplates) render(data presenceTemplateData) (details, state, largeText, smallText string) {
+	var out [4]string
+	for i, field := range t.fields(data) {
+		out[i] = field.builtin
+		if field.template == "" {
+			continue
+		}
+		text, err := executeActivityTemplate(field.template, data)
+		if err != nil {
+			fmt.Printf("Template %s: %v. Using the built-in text.\n", field.name, err)
+			continue
+		}
+		if text == "" {
+			fmt.Printf("Template %s renders empty. Using the built-in text.\n", field.name)
+			continue
+		}
+		out[i] = text
+	}
+	return out[0], out[1], out[2], out[3]
+}
+
+// validate parses every template and renders it with sample data.
+func (t PresenceTemplates) validate(privacy bool) error {
+	data := sampleTemplateData(privacy)
+	for _, field := range t.fields(data) {
+		if field.template == "" {
+			continue
+		}
+		if _, err := renderActivityText(field.template, data); err != nil {
+			return fmt.Errorf("%s: %w", field.name, err)
+		}
+	}
+	return nil
+}
+
+// sanitize clears templates that don't render, so the built-in text is used.
+func (t *PresenceTemplates) sanitize(privacy bool) {
+	data := sampleTemplateData(privacy)
+	for _, field := range []*string{&t.Details, &t.State, &t.LargeText, &t.SmallText} {
+		if *field == "" {
+			continue
+		}
+		if _, err := renderActivityText(*field, data); err != nil {
+			*field = ""
+		}
+	}
+}
+
+// preview renders the templates with sample data, one "Line: text" row per
+// field, with errors inline. The settings window shows it while editing.
+func (t PresenceTemplates) preview(privacy bool) string {
+	names := []string{"Details", "State", "Large image text", "Small image text"}
+	var b strings.Builder
+	for i, field := range t.fields(sampleTemplateData(privacy)) {
+		text := field.builtin
+		if field.template != "" {
+			rendered, err := renderActivityText(field.template, sampleTemplateData(privacy))
+			if err != nil {
+				text = "error: " + err.Error()
+			} else {
+				text = rendered
+			}
+		}
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%s: %s", names[i], text)
+	}
+	return b.String()
+}
+
+// renderActivityText executes one template and checks the result against
+// Discord's length limits, for validating and previewing templates.
+func renderActivityText(text string, data presenceTemplateData) (string, error) {
+	out, err := executeActivityTemplate(text, data)
+	if err != nil {
+		return "", err
+	}
+
+	n := utf8.RuneCountInString(out)
+	if n < minActivityTextRunes || n > maxActivityTextRunes {
+		return "", fmt.Errorf("renders to %d characters (%q), Discord needs %d to %d", n, out, minActivityTextRunes, maxActivityTextRunes)
+	}
+	return out, nil
+}
+
+// executeActivityTemplate executes one template and trims the result.
+func executeActivityTemplate(text string, data presenceTemplateData) (string, error) {
+	tmpl, err := template.New("text").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
+
+// sampleTemplateData stands in for a real Figma state when validating and
+// previewing templates.
+func sampleTemplateData(privacy bool) presenceTemplateData {
+	data := presenceTemplateData{
+		File:       "Landing Page Redesign",
+		Label:      "Working on a project",
+		Mode:       ModeEditing.String(),
+		FileCount:  3,
+		OtherFiles: 2,
+		Elapsed:    "25m",
+		Details:    "Editing File",
+		State:      "Landing Page Redesign",
+		LargeText:  "Figma",
+		SmallText:  "Editing",
+	}
+	if privacy {
+		data.File = ""
+		data.State = data.Label
+	}
+	return data
+}
+
+// formatElapsed renders a duration the way {{.Elapsed}} shows it.
+func formatElapsed(d time.Duration) string {
+	if d < time.Minute {
+		return "0m"
+	}
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh %02dm", int(d.Hours()), int(d.Minutes())%60)
+}
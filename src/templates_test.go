@@ -0,0 +1,77 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestTemplatesFitLongTextsOnPublish(t *testing.T) {
+	options := presenceOptionsFromConfig(DefaultConfig())
+	options.Templates.State = "{{.File}} · {{.Elapsed}}"
+
+	file := strings.Repeat("Quarterly Planning ", 10)
+	figma := FigmaState{Mode: ModeEditing, File: file, FileCount: 1}
+	activity := activityFromFilename(figma, options.privacyFor(file), options, time.Now(), time.Time{})
+
+	if n := utf8.RuneCountInString(activity.State); n != maxActivityTextRunes {
+		t.Errorf("state has %d characters, want %d: %q", n, maxActivityTextRunes, activity.State)
+	}
+	if !strings.HasPrefix(activity.State, "Quarterly Planning") || !strings.HasSuffix(activity.State, "…") {
+		t.Errorf("state = %q, want the template output shortened", activity.State)
+	}
+}
+
+func TestTemplatesFallBack(t *testing.T) {
+	data := sampleTemplateData(false)
+	tests := []struct {
+		template, want string
+	}{
+		{"{{.File}}!", "Landing Page Redesign!"},
+		{"{{.Missing}}", data.State},
+		{"{{if false}}x{{end}}", data.State},
+		{"X", "X"}, // padded later by fitActivityText
+	}
+	for _, tt := range tests {
+		_, state, _, _ := PresenceTemplates{State: tt.template}.render(data)
+		if state != tt.want {
+			t.Errorf("render(%q) = %q, want %q", tt.template, state, tt.want)
+		}
+	}
+}
+
+func TestTemplatesValidateLength(t *testing.T) {
+	long := PresenceTemplates{State: strings.Repeat("x", maxActivityTextRunes+1)}
+	if err := long.validate(false); err == nil {
+		t.Error("validate accepted a template longer than Discord allows")
+	}
+	if err := (PresenceTemplates{State: "{{.File}}"}).validate(false); err != nil {
+		t.Errorf("validate: %v", err)
+	}
+}
+
+func TestTemplatesRenderIdleActivity(t *testing.T) {
+	options := presenceOptionsFromConfig(DefaultConfig())
+	figma := FigmaState{Mode: ModeEditing, File: "Logo", FileCount: 1}
+	start := time.Now().Add(-time.Hour)
+	idleSince := time.Now().Add(-10 * time.Minute)
+
+	activity := activityFromFilename(figma, options.privacyFor(figma.File), options, start, idleSince)
+	if activity.Details != "Idle" || activity.State != "Logo" || !activity.Start.Equal(idleSince) {
+		t.Errorf("idle activity = %+v", activity)
+	}
+	if activity.SmallImage != options.Assets.Idle.Image || activity.SmallText != options.Assets.Idle.Text {
+		t.Errorf("small image = %q %q, want the idle image", activity.SmallImage, activity.SmallText)
+	}
+
+	options.Templates.Details = "{{if .Idle}}Away for {{.Elapsed}}{{else}}{{.Details}}{{end}}"
+	activity = activityFromFilename(figma, options.privacyFor(figma.File), options, start, idleSince)
+	if activity.Details != "Away for 10m" {
+		t.Errorf("idle details = %q, want the template output", activity.Details)
+	}
+	activity = activityFromFilename(figma, options.privacyFor(figma.File), options, start, time.Time{})
+	if activity.Details != "Editing File" {
+		t.Errorf("active details = %q, want the built-in text", activity.Details)
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import "time"
+
+const (
+	timerModeFile    = "file"    // Restart the timer for each file
+	timerModeSession = "session" // One timer since the app connected to Discord
+	timerModeDay     = "day"     // One timer per calendar day, from the first activity
+)
+
+// fileTimerResumeWindow is how long a file keeps its timer after switching
+// away from it. Coming back within this window resumes the old start time.
+const fileTimerResumeWindow = 5 * time.Minute
+
+// sessionTimer decides the start timestamp shown in Discord's elapsed timer.
+// It takes its clock as a function so it can be driven deterministically.
+type sessionTimer struct {
+	mode string
+	now  func() time.Time
+
+	sessionStart time.Time
+	dayStart     time.Time // First activity of the current day; zero before any
+	current      string
+	files        map[string]*fileTimer
+}
+
+type fileTimer struct {
+	start  time.Time
+	leftAt time.Time // Zero while the file is the current one
+}
+
+func newSessionTimer(mode string, now func() time.Time) *sessionTimer {
+	t := &sessionTimer{
+		mode:  sanitizeTimerMode(mode),
+		now:   now,
+		files: make(map[string]*fileTimer),
+	}
+	t.Reset()
+	return t
+}
+
+// Reset starts a new app session, e.g. after reconnecting to Discord. The
+// per-day timer is not affected.
+func (t *sessionTimer) Reset() {
+	now := t.now()
+	t.sessionStart = now
+	t.files = make(map[string]*fileTimer)
+	if t.current != "" {
+		t.files[t.current] = &fileTimer{start: now}
+	}
+}
+
+// SetMode switches between timer modes without losing tracked files.
+func (t *sessionTimer) SetMode(mode string) {
+	t.mode = sanitizeTimerMode(mode)
+}
+
+// Switch records which file (or view) is current. An empty key means Figma
+// is closed.
+func (t *sessionTimer) Switch(key string) {
+	if key == t.current {
+		return
+	}
+
+	now := t.now()
+	t.rollDay(now)
+	if previous, ok := t.files[t.current]; ok {
+		previous.leftAt = now
+	}
+	t.current = key
+	t.rollDay(now)
+
+	for name, file := range t.files {
+		if !file.leftAt.IsZero() && now.Sub(file.leftAt) > fileTimerResumeWindow {
+			delete(t.files, name)
+		}
+	}
+
+	if key == "" {
+		return
+	}
+	if file, ok := t.files[key]; ok {
+		file.leftAt = time.Time{}
+		return
+	}
+	t.files[key] = &fileTimer{start: now}
+}
+
+// Start returns the timestamp Discord should count from.
+func (t *sessionTimer) Start() time.Time {
+	now := t.now()
+
+	switch t.mode {
+	case timerModeSession:
+		return t.sessionStart
+	case timerModeDay:
+		if start := t.rollDay(now); !start.IsZero() {
+			return start
+		}
+		return now
+	default:
+		if file, ok := t.files[t.current]; ok {
+			return file.start
+		}
+		return now
+	}
+}
+
+// rollDay keeps dayStart on the current day and returns it. Work going on
+// at midnight counts from midnight on the new day; otherwise the day starts
+// with the first file or view opened.
+func (t *sessionTimer) rollDay(now time.Time) time.Time {
+	if !t.dayStart.IsZero() && !sameDay(t.dayStart, now) {
+		t.dayStart = time.Time{}
+		if t.current != "" {
+			t.dayStart = startOfDay(now)
+		}
+	}
+	if t.dayStart.IsZero() && t.current != "" {
+		t.dayStart = now
+	}
+	return t.dayStart
+}
+
+// NextRollover returns when Start changes on its own: the next local
+// midnight in day mode. Other modes only change with the file, so it is zero.
+func (t *sessionTimer) NextRollover() time.Time {
+	if t.mode != timerModeDay {
+		return time.Time{}
+	}
+	return startOfDay(t.now()).AddDate(0, 0, 1)
+}
+
+// timerKey identifies what the per-file timer tracks for a FigmaState.
+func timerKey(figma FigmaState) string {
+	if figma.Mode == ModeClosed {
+		return ""
+	}
+	if figma.File != "" {
+		return "file:" + figma.File
+	}
+	return "view:" + figma.Mode.String()
+}
+
+func sanitizeTimerMode(mode string) string {
+	switch mode {
+	case timerModeSession, timerModeDay:
+		return mode
+	default:
+		return timerModeFile
+	}
+}
+
+func sameDay(a, b time.Time) bool {
+	ay, am, ad := a.Local().Date()
+	by, bm, bd := b.Local().Date()
+	return ay == by && am == bm && ad == bd
+}
+
+// startOfDay returns local midnight at the start of t's day.
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Local().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.Local)
+}
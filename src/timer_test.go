@@ -0,0 +1,130 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFileTimerResumeWindow(t *testing.T) {
+	tests := []struct {
+		name    string
+		away    time.Duration
+		via     string // What is current meanwhile; empty for Figma closed
+		resumes bool
+	}{
+		{"inside the window", 2 * time.Minute, "file:Brand", true},
+		{"inside the window, Figma closed", 2 * time.Minute, "", true},
+		{"at the boundary", fileTimerResumeWindow, "file:Brand", true},
+		{"just outside the window", fileTimerResumeWindow + time.Second, "file:Brand", false},
+		{"outside the window, Figma closed", time.Hour, "", false},
+	}
+	for _, tt := range tests {
+		clock := newFakeClock()
+		timer := newSessionTimer(timerModeFile, clock.Now)
+
+		timer.Switch("file:Logo")
+		start := timer.Start()
+		clock.Advance(10 * time.Minute)
+
+		timer.Switch(tt.via)
+		clock.Advance(tt.away)
+		timer.Switch("file:Logo")
+
+		want := clock.Now()
+		if tt.resumes {
+			want = start
+		}
+		if got := timer.Start(); !got.Equal(want) {
+			t.Errorf("%s: start = %s, want %s", tt.name, got.Format(time.TimeOnly), want.Format(time.TimeOnly))
+		}
+	}
+}
+
+func TestTimerModes(t *testing.T) {
+	clock := newFakeClock()
+	appStart := clock.Now()
+	timer := newSessionTimer(timerModeSession, clock.Now)
+
+	timer.Switch("file:Logo")
+	clock.Advance(time.Hour)
+	timer.Switch("file:Brand")
+	if got := timer.Start(); !got.Equal(appStart) {
+		t.Errorf("session start = %s, want %s", got, appStart)
+	}
+
+	timer.SetMode(timerModeFile)
+	if got := timer.Start(); !got.Equal(clock.Now()) {
+		t.Errorf("file start = %s, want %s", got, clock.Now())
+	}
+
+	timer.SetMode(timerModeDay)
+	if got := timer.Start(); !got.Equal(appStart) {
+		t.Errorf("day start = %s, want the first file at %s", got, appStart)
+	}
+}
+
+func TestDayTimerStartsWithTheFirstActivity(t *testing.T) {
+	clock := newFakeClock()
+	clock.now = time.Date(2026, 3, 2, 14, 0, 0, 0, time.Local)
+	timer := newSessionTimer(timerModeDay, clock.Now)
+
+	steps := []struct {
+		at   time.Time
+		key  string
+		want time.Time // Zero while Figma is closed
+	}{
+		{time.Date(2026, 3, 2, 14, 0, 0, 0, time.Local), "file:Logo", time.Date(2026, 3, 2, 14, 0, 0, 0, time.Local)},
+		{time.Date(2026, 3, 2, 15, 0, 0, 0, time.Local), "file:Brand", time.Date(2026, 3, 2, 14, 0, 0, 0, time.Local)},
+		{time.Date(2026, 3, 2, 16, 0, 0, 0, time.Local), "", time.Time{}},
+		{time.Date(2026, 3, 2, 18, 0, 0, 0, time.Local), "file:Logo", time.Date(2026, 3, 2, 14, 0, 0, 0, time.Local)},
+		{time.Date(2026, 3, 2, 19, 0, 0, 0, time.Local), "", time.Time{}},
+		{time.Date(2026, 3, 3, 10, 30, 0, 0, time.Local), "view:home", time.Date(2026, 3, 3, 10, 30, 0, 0, time.Local)},
+	}
+	for _, step := range steps {
+		clock.now = step.at
+		timer.Switch(step.key)
+		if step.want.IsZero() {
+			continue
+		}
+		if got := timer.Start(); !got.Equal(step.want) {
+			t.Errorf("%s at %s: start = %s, want %s", step.key, step.at.Format(time.DateTime), got.Format(time.DateTime), step.want.Format(time.DateTime))
+		}
+	}
+}
+
+func TestDayTimerRollsOverAtMidnight(t *testing.T) {
+	clock := newFakeClock()
+	clock.now = time.Date(2026, 3, 2, 23, 50, 0, 0, time.Local)
+	timer := newSessionTimer(timerModeDay, clock.Now)
+	timer.Switch("file:Logo")
+
+	opened := clock.Now()
+	tomorrow := time.Date(2026, 3, 3, 0, 0, 0, 0, time.Local)
+	if got := timer.Start(); !got.Equal(opened) {
+		t.Errorf("start = %s, want when Logo was opened %s", got, opened)
+	}
+	if got := timer.NextRollover(); !got.Equal(tomorrow) {
+		t.Errorf("next rollover = %s, want %s", got, tomorrow)
+	}
+
+	// Reconnecting does not restart the day.
+	clock.Advance(5 * time.Minute)
+	timer.Reset()
+	if got := timer.Start(); !got.Equal(opened) {
+		t.Errorf("start after Reset = %s, want %s", got, opened)
+	}
+
+	// Still working at midnight: the new day counts from midnight.
+	clock.Advance(10 * time.Minute)
+	if got := timer.Start(); !got.Equal(tomorrow) {
+		t.Errorf("start after midnight = %s, want %s", got, tomorrow)
+	}
+	if got, want := timer.NextRollover(), tomorrow.AddDate(0, 0, 1); !got.Equal(want) {
+		t.Errorf("next rollover after midnight = %s, want %s", got, want)
+	}
+
+	timer.SetMode(timerModeFile)
+	if got := timer.NextRollover(); !got.IsZero() {
+		t.Errorf("file mode rollover = %s, want none", got)
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import "strings"
+
+// FigmaMode describes what the user is doing in Figma.
+type FigmaMode int
+
+const (
+	ModeClosed FigmaMode = iota // Figma is not running or has no window
+	ModeEditing
+	ModeHome
+	ModeDrafts
+	ModePrototype
+	ModeDevMode
+	ModeFigJam
+)
+
+func (m FigmaMode) String() string {
+	switch m {
+	case ModeEditing:
+		return "editing"
+	case ModeHome:
+		return "home"
+	case ModeDrafts:
+		return "drafts"
+	case ModePrototype:
+		return "prototype"
+	case ModeDevMode:
+		return "dev mode"
+	case ModeFigJam:
+		return "figjam"
+	default:
+		return "closed"
+	}
+}
+
+// FigmaState is a snapshot of the Figma window that the presence is built from.
+type FigmaState struct {
+	Mode       FigmaMode
+	File       string // File name, empty outside of a file
+	Page       string // Page name, when the window title includes one
+	RawTitle   string // Window title as reported by the TitleSource
+	FileCount  int    // Number of distinct files open across all Figma windows
+	OtherFiles int    // Files of FileCount open in other windows than this one
+}
+
+// titleRank orders window kinds when no window is focused.
+type titleRank int
+
+const (
+	rankFile     titleRank = iota // A file, board, prototype or Dev Mode window
+	rankBrowsing                  // Home or Drafts
+	rankFallback                  // Figma window without a recognizable title
+	rankNone                      // Not a usable Figma title
+)
+
+// parseFigmaState classifies every Figma window and picks the one to report:
+// the focused window first, then the window reported last time (lastTitle) if
+// it is still open, so switching to another app does not jump between files.
+// Otherwise file windows win over Home/Drafts, and titles without a Figma
+// suffix are used only as a last resort.
+//
+// Recognized title shapes (any of "-", "–" or "—" as separator):
+//
+//	Design System – Figma                  editing
+//	Home – Figma / Drafts – Figma          home / drafts
+//	Design System – Dev Mode – Figma       dev mode
+//	Checkout – Flows – Prototype – Figma   prototype, page "Flows"
+//	Sprint Retro – FigJam                  figjam
+func parseFigmaState(windows []FigmaWindow, lastTitle string) FigmaState {
+	var best, focused, previous FigmaState
+	bestRank := rankNone
+	files := make(map[string]struct{})
+
+	for _, window := range windows {
+		figma, rank := classifyWindowTitle(window.Title)
+		if rank == rankNone {
+			continue
+		}
+		if rank == rankFile {
+			files[figma.File] = struct{}{}
+		}
+
+		if window.Focused && focused.Mode == ModeClosed {
+			focused = figma
+		}
+		if lastTitle != "" && window.Title == lastTitle {
+			previous = figma
+		}
+		if rank < bestRank {
+			best, bestRank = figma, rank
+		}
+	}
+
+	picked := best
+	if focused.Mode != ModeClosed {
+		picked = focused
+	} else if previous.Mode != ModeClosed {
+		picked = previous
+	}
+
+	if picked.Mode != ModeClosed {
+		picked.FileCount = len(files)
+		picked.OtherFiles = len(files)
+		// A window without a Figma suffix is not counted, unless another
+		// window has the same file open.
+		if _, counted := files[picked.File]; counted {
+			picked.OtherFiles--
+		}
+	}
+	return picked
+}
+
+// classifyWindowTitle turns a single Figma window title into a FigmaState.
+func classifyWindowTitle(title string) (FigmaState, titleRank) {
+	if board, ok := trimFigJamSuffix(title); ok && board != "" {
+		return FigmaState{Mode: ModeFigJam, File: board, RawTitle: title}, rankFile
+	}
+
+	base, ok := trimFigmaSuffix(title)
+	if !ok {
+		trimmed := strings.TrimSpace(title)
+		lowerTitle := strings.ToLower(trimmed)
+		switch {
+		case strings.Contains(lowerTitle, "home"):
+			return FigmaState{Mode: ModeHome, RawTitle: title}, rankBrowsing
+		case strings.Contains(lowerTitle, "drafts"):
+			return FigmaState{Mode: ModeDrafts, RawTitle: title}, rankBrowsing
+		case trimmed != "":
+			return FigmaState{Mode: ModeEditing, File: trimmed, RawTitle: title}, rankFallback
+		}
+		return FigmaState{}, rankNone
+	}
+
+	switch strings.ToLower(base) {
+	case "home":
+		return FigmaState{Mode: ModeHome, RawTitle: title}, rankBrowsing
+	case "drafts":
+		return FigmaState{Mode: ModeDrafts, RawTitle: title}, rankBrowsing
+	case "":
+		return FigmaState{}, rankNone
+	}
+
+	figma := classifyFileTitle(base)
+	figma.RawTitle = title
+	return figma, rankFile
+}
+
+// figmaModeMarkers maps a title segment to the mode it announces.
+var figmaModeMarkers = map[string]FigmaMode{
+	"dev mode":     ModeDevMode,
+	"prototype":    ModePrototype,
+	"presentation": ModePrototype,
+	"present":      ModePrototype,
+}
+
+// titleSeparators are the dashes Figma uses between title segments.
+var titleSeparators = []string{" - ", " \u2013 ", " \u2014 "}
+
+// classifyFileTitle turns the part of a file window title before " – Figma"
+// into a FigmaState. A trailing mode marker segment switches the mode, and a
+// segment between the file name and the marker is taken as the page name.
+// Without a marker the whole base is the file name, since file names often
+// contain dashes themselves.
+func classifyFileTitle(base string) FigmaState {
+	segments := splitTitleSegments(base)
+	if len(segments) < 2 {
+		return FigmaState{Mode: ModeEditing, File: base}
+	}
+
+	mode, ok := figmaModeMarkers[strings.ToLower(segments[len(segments)-1])]
+	if !ok {
+		return FigmaState{Mode: ModeEditing, File: base}
+	}
+
+	segments = segments[:len(segments)-1]
+	figma := FigmaState{Mode: mode, File: segments[0]}
+	if len(segments) > 1 {
+		figma.File = strings.Join(segments[:len(segments)-1], " \u2013 ")
+		figma.Page = segments[len(segments)-1]
+	}
+	return figma
+}
+
+// splitTitleSegments splits a title on any of the titleSeparators.
+func splitTitleSegments(title string) []string {
+	normalized := title
+	for _, sep := range titleSeparators[1:] {
+		normalized = strings.ReplaceAll(normalized, sep, titleSeparators[0])
+	}
+
+	parts := strings.Split(normalized, titleSeparators[0])
+	segments := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			segments = append(segments, part)
+		}
+	}
+	return segments
+}
+
+// isFigmaWindowTitle reports whether a window title belongs to Figma or FigJam.
+func isFigmaWindowTitle(title string) bool {
+	if _, ok := trimFigmaSuffix(title); ok {
+		return true
+	}
+	_, ok := trimFigJamSuffix(title)
+	return ok
+}
+
+func trimFigJamSuffix(title string) (string, bool) {
+	for _, sep := range titleSeparators {
+		suffix := strings.TrimRight(sep, " ") + " FigJam"
+		if strings.HasSuffix(title, suffix) {
+			return strings.TrimSpace(strings.TrimSuffix(title, suffix)), true
+		}
+	}
+
+	return "", false
+}
+
+func trimFigmaSuffix(title string) (string, bool) {
+	suffixes := []string{
+		" - Figma",
+		" \u2013 Figma",
+		" \u2014 Figma",
+	}
+
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(title, suffix) {
+			return strings.TrimSpace(strings.TrimSuffix(title, suffix)), true
+		}
+	}
+
+	return "", false
+}
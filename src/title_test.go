@@ -0,0 +1,125 @@
+package main
+
+import "testing"
+
+func TestClassifyWindowTitle(t *testing.T) {
+	tests := []struct {
+		title string
+		want  FigmaState
+		rank  titleRank
+	}{
+		{"Design System – Figma", FigmaState{Mode: ModeEditing, File: "Design System"}, rankFile},
+		{"Design System - Figma", FigmaState{Mode: ModeEditing, File: "Design System"}, rankFile},
+		{"Design System — Figma", FigmaState{Mode: ModeEditing, File: "Design System"}, rankFile},
+		{"Q3 - Roadmap – Figma", FigmaState{Mode: ModeEditing, File: "Q3 - Roadmap"}, rankFile},
+		{"Home – Figma", FigmaState{Mode: ModeHome}, rankBrowsing},
+		{"Drafts – Figma", FigmaState{Mode: ModeDrafts}, rankBrowsing},
+
+		// Mode markers, with and without a page segment.
+		{"Design System – Dev Mode – Figma", FigmaState{Mode: ModeDevMode, File: "Design System"}, rankFile},
+		{"Design System – dev mode – Figma", FigmaState{Mode: ModeDevMode, File: "Design System"}, rankFile},
+		{"Checkout – Prototype – Figma", FigmaState{Mode: ModePrototype, File: "Checkout"}, rankFile},
+		{"Checkout – Flows – Prototype – Figma", FigmaState{Mode: ModePrototype, File: "Checkout", Page: "Flows"}, rankFile},
+		{"Checkout – Present – Figma", FigmaState{Mode: ModePrototype, File: "Checkout"}, rankFile},
+		{"Checkout – Presentation – Figma", FigmaState{Mode: ModePrototype, File: "Checkout"}, rankFile},
+		{"Acme – Checkout – Flows – Prototype – Figma", FigmaState{Mode: ModePrototype, File: "Acme – Checkout", Page: "Flows"}, rankFile},
+
+		// FigJam suffixes.
+		{"Sprint Retro – FigJam", FigmaState{Mode: ModeFigJam, File: "Sprint Retro"}, rankFile},
+		{"Sprint Retro - FigJam", FigmaState{Mode: ModeFigJam, File: "Sprint Retro"}, rankFile},
+		{"Sprint Retro — FigJam", FigmaState{Mode: ModeFigJam, File: "Sprint Retro"}, rankFile},
+
+		// Titles without a Figma suffix.
+		{"Figma Home", FigmaState{Mode: ModeHome}, rankBrowsing},
+		{"Untitled", FigmaState{Mode: ModeEditing, File: "Untitled"}, rankFallback},
+		{"", FigmaState{}, rankNone},
+		{" – Figma", FigmaState{}, rankNone},
+	}
+	for _, tt := range tests {
+		got, rank := classifyWindowTitle(tt.title)
+		if tt.want.Mode != ModeClosed {
+			tt.want.RawTitle = tt.title
+		}
+		if got != tt.want || rank != tt.rank {
+			t.Errorf("classifyWindowTitle(%q) = %+v, %d; want %+v, %d", tt.title, got, rank, tt.want, tt.rank)
+		}
+	}
+}
+
+func TestParseFigmaState(t *testing.T) {
+	tests := []struct {
+		name      string
+		windows   []FigmaWindow
+		lastTitle string
+		want      string // RawTitle of the picked window
+		files     int
+		others    int
+	}{
+		{
+			name:    "no windows",
+			windows: nil,
+		},
+		{
+			name:    "focused window wins",
+			windows: []FigmaWindow{{Title: "Logo – Figma"}, {Title: "Brand – Figma", Focused: true}},
+			want:    "Brand – Figma",
+			files:   2,
+			others:  1,
+		},
+		{
+			name:      "focused wins over the previous window",
+			windows:   []FigmaWindow{{Title: "Logo – Figma"}, {Title: "Home – Figma", Focused: true}},
+			lastTitle: "Logo – Figma",
+			want:      "Home – Figma",
+			files:     1,
+			others:    1,
+		},
+		{
+			name:      "previous window without focus",
+			windows:   []FigmaWindow{{Title: "Logo – Figma"}, {Title: "Brand – Figma"}},
+			lastTitle: "Brand – Figma",
+			want:      "Brand – Figma",
+			files:     2,
+			others:    1,
+		},
+		{
+			name:      "closed previous window falls back to the ranking",
+			windows:   []FigmaWindow{{Title: "Home – Figma"}, {Title: "Logo – Figma"}},
+			lastTitle: "Brand – Figma",
+			want:      "Logo – Figma",
+			files:     1,
+		},
+		{
+			name:    "files rank above Home and untitled windows",
+			windows: []FigmaWindow{{Title: "Untitled"}, {Title: "Drafts – Figma"}, {Title: "Retro – FigJam"}},
+			want:    "Retro – FigJam",
+			files:   1,
+		},
+		{
+			name:    "Home ranks above untitled windows",
+			windows: []FigmaWindow{{Title: "Untitled"}, {Title: "Home – Figma"}},
+			want:    "Home – Figma",
+			files:   0,
+		},
+		{
+			name:    "files are counted once across windows and modes",
+			windows: []FigmaWindow{{Title: "Logo – Figma", Focused: true}, {Title: "Logo – Dev Mode – Figma"}, {Title: "Brand – Figma"}, {Title: "Home – Figma"}},
+			want:    "Logo – Figma",
+			files:   2,
+			others:  1,
+		},
+		{
+			name:    "a focused window without a Figma suffix is not one of the counted files",
+			windows: []FigmaWindow{{Title: "Logo – Figma"}, {Title: "Brand – Figma"}, {Title: "Untitled", Focused: true}},
+			want:    "Untitled",
+			files:   2,
+			others:  2,
+		},
+	}
+	for _, tt := range tests {
+		got := parseFigmaState(tt.windows, tt.lastTitle)
+		if got.RawTitle != tt.want || got.FileCount != tt.files || got.OtherFiles != tt.others {
+			t.Errorf("%s: picked %q with %d files, %d others; want %q with %d, %d others", tt.name, got.RawTitle, got.FileCount, got.OtherFiles, tt.want, tt.files, tt.others)
+		}
+	}
+}
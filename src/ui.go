@@ -16,6 +16,7 @@ import (
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
 
 	_ "image/gif"
@@ -33,15 +34,19 @@ const (
 var (
 	colorConnected    = color.NRGBA{R: 76, G: 175, B: 80, A: 255}
 	colorDisconnected = color.NRGBA{R: 244, G: 67, B: 54, A: 255}
+	colorWaiting      = color.NRGBA{R: 255, G: 179, B: 0, A: 255}
 	colorCardFill     = color.NRGBA{R: 10, G: 10, B: 10, A: 209}
 	colorCardStroke   = color.NRGBA{R: 255, G: 255, B: 255, A: 36}
 )
 
-// UIEvents carries signals from the UI to the RPC loop.
+// UIEvents carries signals from the UI to the RPC loop, and the RPC loop's
+// status back to the UI.
 type UIEvents struct {
 	Disconnect    chan struct{}
 	Reconnect     chan struct{}
 	ConfigChanged chan *Config
+	Status        chan rpcStatus
+	Pause         chan pauseState // The zero pause resumes
 }
 
 // NewUIEvents creates a new UIEvents with buffered channels.
@@ -50,6 +55,8 @@ func NewUIEvents() *UIEvents {
 		Disconnect:    make(chan struct{}, 1),
 		Reconnect:     make(chan struct{}, 1),
 		ConfigChanged: make(chan *Config, 1),
+		Status:        make(chan rpcStatus, 1),
+		Pause:         make(chan pauseState, 1),
 	}
 }
 
@@ -57,26 +64,38 @@ func NewUIEvents() *UIEvents {
 type statusIndicator struct {
 	circle *canvas.Circle
 	label  *widget.Label
+	resume *widget.Button // Shown while the user has paused the presence
 }
 
 func newStatusIndicator() *statusIndicator {
-	circle := canvas.NewCircle(colorConnected)
-	label := widget.NewLabel("Connected")
+	circle := canvas.NewCircle(colorWaiting)
+	label := widget.NewLabel("Starting")
 	label.TextStyle = fyne.TextStyle{Bold: true}
 
 	return &statusIndicator{circle: circle, label: label}
 }
 
-func (s *statusIndicator) setConnected() {
-	s.circle.FillColor = colorConnected
+// setStatus shows a status reported by the RPC manager. Must run on the Fyne
+// main thread.
+func (s *statusIndicator) setStatus(status rpcStatus) {
+	switch status.Kind {
+	case statusConnected, statusPublishing:
+		s.circle.FillColor = colorConnected
+	case statusDisabled, statusError:
+		s.circle.FillColor = colorDisconnected
+	default:
+		s.circle.FillColor = colorWaiting
+	}
 	s.circle.Refresh()
-	s.label.SetText("Connected")
-}
+	s.label.SetText(status.Text())
 
-func (s *statusIndicator) setDisconnected() {
-	s.circle.FillColor = colorDisconnected
-	s.circle.Refresh()
-	s.label.SetText("Disconnected")
+	if s.resume != nil {
+		if status.Kind == statusPaused && (status.UntilFigmaCloses || !status.PausedUntil.IsZero()) {
+			s.resume.Show()
+		} else {
+			s.resume.Hide()
+		}
+	}
 }
 
 // AppUI holds all the Fyne app components.
@@ -87,6 +106,9 @@ type AppUI struct {
 	Events *UIEvents
 	Config *Config
 	Status *statusIndicator
+
+	trayMenu   *fyne.Menu
+	trayHeader *fyne.MenuItem // Disabled first item showing the status
 }
 
 // SetupUI creates the Fyne application, window, system tray, and all widgets.
@@ -100,7 +122,7 @@ func SetupUI(cfg *Config, events *UIEvents) *AppUI {
 	}
 
 	win := fyneApp.NewWindow(fmt.Sprintf("Figma Discord Rich Presence  v%s", appVersion))
-	win.Resize(fyne.NewSize(460, 500))
+	win.Resize(fyne.NewSize(460, 640))
 	win.SetFixedSize(true)
 	win.CenterOnScreen()
 
@@ -113,7 +135,7 @@ func SetupUI(cfg *Config, events *UIEvents) *AppUI {
 		Status: newStatusIndicator(),
 	}
 	if !cfg.RPCEnabled {
-		ui.Status.setDisconnected()
+		ui.Status.setStatus(rpcStatus{Kind: statusDisabled})
 	}
 
 	// Build the window content
@@ -127,6 +149,8 @@ func SetupUI(cfg *Config, events *UIEvents) *AppUI {
 	// Setup system tray
 	ui.setupSystemTray()
 
+	go ui.watchStatus()
+
 	return ui
 }
 
@@ -172,11 +196,9 @@ func loadAppIconResource() fyne.Resource {
 }
 
 func (ui *AppUI) handleDisconnectAction() {
-	ui.Config.RPCEnabled = false
-	if err := ui.Config.Save(); err != nil {
+	if err := ui.Config.SetRPCEnabled(false); err != nil {
 		fmt.Println("Error saving config:", err)
 	}
-	ui.Status.setDisconnected()
 	select {
 	case ui.Events.Disconnect <- struct{}{}:
 	default:
@@ -184,11 +206,9 @@ func (ui *AppUI) handleDisconnectAction() {
 }
 
 func (ui *AppUI) handleReconnectAction() {
-	ui.Config.RPCEnabled = true
-	if err := ui.Config.Save(); err != nil {
+	if err := ui.Config.SetRPCEnabled(true); err != nil {
 		fmt.Println("Error saving config:", err)
 	}
-	ui.Status.setConnected()
 	select {
 	case ui.Events.Reconnect <- struct{}{}:
 	default:
@@ -247,16 +267,20 @@ func (ui *AppUI) buildContent() fyne.CanvasObject {
 		ui.Status.label,
 	)
 	statusRow := container.NewCenter(statusRowContent)
+	ui.Status.resume = widget.NewButton("Resume Now", func() {
+		ui.requestPause(pauseState{})
+	})
+	ui.Status.resume.Hide()
 	statusCard := sectionCard(
 		sectionHeader("Status", "Current Discord Rich Presence connection."),
 		spacer(8),
 		statusRow,
+		container.NewCenter(ui.Status.resume),
 	)
 
 	// Privacy section
 	privacyCheck := widget.NewCheck("Privacy Mode", func(checked bool) {
-		ui.Config.PrivacyMode = checked
-		if err := ui.Config.Save(); err != nil {
+		if err := ui.Config.SetPrivacyMode(checked); err != nil {
 			fmt.Println("Error saving config:", err)
 		}
 		ui.notifyConfigChanged()
@@ -276,8 +300,7 @@ func (ui *AppUI) buildContent() fyne.CanvasObject {
 
 		latestText := text
 		customLabelDebounceTimer = time.AfterFunc(5*time.Second, func() {
-			ui.Config.CustomLabel = latestText
-			if err := ui.Config.Save(); err != nil {
+			if err := ui.Config.SetCustomLabel(latestText); err != nil {
 				fmt.Println("Error saving config:", err)
 			}
 			ui.notifyConfigChanged()
@@ -292,6 +315,20 @@ func (ui *AppUI) buildContent() fyne.CanvasObject {
 	customLabelLabel := widget.NewLabel("Replacement Label")
 	customLabelLabel.TextStyle = fyne.TextStyle{Bold: true}
 
+	newRedactionCheck := func(text, builtin string) *widget.Check {
+		check := widget.NewCheck(text, func(checked bool) {
+			if err := ui.Config.SetRedactions(withBuiltinRedaction(ui.Config.Redactions, builtin, checked)); err != nil {
+				fmt.Println("Error saving config:", err)
+			}
+			ui.notifyConfigChanged()
+		})
+		check.Checked = hasBuiltinRedaction(ui.Config.Redactions, builtin)
+		return check
+	}
+
+	redactLabel := widget.NewLabel("Mask in File Names")
+	redactLabel.TextStyle = fyne.TextStyle{Bold: true}
+
 	privacyCard := sectionCard(
 		sectionHeader("Privacy", "Hide project names and replace them with your custom text."),
 		spacer(8),
@@ -299,6 +336,78 @@ func (ui *AppUI) buildContent() fyne.CanvasObject {
 		spacer(4),
 		customLabelLabel,
 		customLabelEntry,
+		spacer(4),
+		redactLabel,
+		newRedactionCheck("Email addresses", redactBuiltinEmail),
+		newRedactionCheck("Ticket IDs like ACME-123", redactBuiltinTicket),
+		newRedactionCheck("Text in [square brackets]", redactBuiltinBrackets),
+	)
+
+	// Presence section
+	otherFilesCheck := widget.NewCheck("Show count of other open files", func(checked bool) {
+		if err := ui.Config.SetShowOtherFiles(checked); err != nil {
+			fmt.Println("Error saving config:", err)
+		}
+		ui.notifyConfigChanged()
+	})
+	otherFilesCheck.Checked = ui.Config.ShowOtherFiles
+
+	idleTimeoutSelect := widget.NewSelect(idleTimeoutOptions, func(selected string) {
+		if err := ui.Config.SetIdleTimeoutMinutes(idleTimeoutMinutesFromOption(selected)); err != nil {
+			fmt.Println("Error saving config:", err)
+		}
+		ui.notifyConfigChanged()
+	})
+	idleTimeoutSelect.Selected = idleTimeoutOptionFromMinutes(ui.Config.IdleTimeoutMinutes)
+
+	idleActionSelect := widget.NewSelect([]string{idleActionShowOption, idleActionClearOption}, func(selected string) {
+		action := idleActionShow
+		if selected == idleActionClearOption {
+			action = idleActionClear
+		}
+		if err := ui.Config.SetIdleAction(action); err != nil {
+			fmt.Println("Error saving config:", err)
+		}
+		ui.notifyConfigChanged()
+	})
+	idleActionSelect.Selected = idleActionShowOption
+	if sanitizeIdleAction(ui.Config.IdleAction) == idleActionClear {
+		idleActionSelect.Selected = idleActionClearOption
+	}
+
+	timerModeSelect := widget.NewSelect(timerModeOptions, func(selected string) {
+		if err := ui.Config.SetTimerMode(timerModeFromOption(selected)); err != nil {
+			fmt.Println("Error saving config:", err)
+		}
+		ui.notifyConfigChanged()
+	})
+	timerModeSelect.Selected = timerModeOptionFromMode(ui.Config.TimerMode)
+
+	journalCheck := widget.NewCheck("Keep a local time-tracking journal", func(checked bool) {
+		if err := ui.Config.SetJournalEnabled(checked); err != nil {
+			fmt.Println("Error saving config:", err)
+		}
+		ui.notifyConfigChanged()
+	})
+	journalCheck.Checked = ui.Config.JournalEnabled
+
+	timerLabel := widget.NewLabel("Elapsed Timer")
+	timerLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	idleLabel := widget.NewLabel("When Idle")
+	idleLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	presenceCard := sectionCard(
+		sectionHeader("Presence", "Choose what Discord shows while you work or step away."),
+		spacer(8),
+		otherFilesCheck,
+		journalCheck,
+		spacer(4),
+		timerLabel,
+		timerModeSelect,
+		spacer(4),
+		idleLabel,
+		container.NewGridWithColumns(2, idleTimeoutSelect, idleActionSelect),
 	)
 
 	// Connection section
@@ -308,10 +417,63 @@ func (ui *AppUI) buildContent() fyne.CanvasObject {
 	reconnectBtn := widget.NewButton("Reconnect", ui.handleReconnectAction)
 	reconnectBtn.Importance = widget.SuccessImportance
 
+	clearPolicySelect := widget.NewSelect([]string{clearPolicyClearOption, clearPolicyDisconnectOption}, func(selected string) {
+		policy := clearPolicyClear
+		if selected == clearPolicyDisconnectOption {
+			policy = clearPolicyDisconnect
+		}
+		if err := ui.Config.SetClearPolicy(policy); err != nil {
+			fmt.Println("Error saving config:", err)
+		}
+		ui.notifyConfigChanged()
+	})
+	clearPolicySelect.Selected = clearPolicyClearOption
+	if sanitizeClearPolicy(ui.Config.ClearPolicy) == clearPolicyDisconnect {
+		clearPolicySelect.Selected = clearPolicyDisconnectOption
+	}
+
+	clearPolicyLabel := widget.NewLabel("When Figma Closes")
+	clearPolicyLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	clientIDEntry := widget.NewEntry()
+	clientIDEntry.SetPlaceHolder(discordClientID)
+	clientIDEntry.SetText(ui.Config.ClientID)
+	clientIDEntry.Validator = validateClientID
+	applyClientID := func() {
+		clientID := clientIDEntry.Text
+		if clientID == "" {
+			clientID = discordClientID
+			clientIDEntry.SetText(clientID)
+		}
+		if clientID == ui.Config.ClientID {
+			return
+		}
+		if err := ui.Config.SetClientID(clientID); err != nil {
+			fmt.Println("Could not change application ID:", err)
+			return
+		}
+		ui.notifyConfigChanged()
+	}
+	clientIDEntry.OnSubmitted = func(string) { applyClientID() }
+	clientIDApply := widget.NewButton("Apply", applyClientID)
+
+	clientIDLabel := widget.NewLabel("Discord Application ID")
+	clientIDLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	assetsHint := widget.NewLabel("Images and hover texts: figma-rpc config set assets.<name>.image")
+	assetsHint.Wrapping = fyne.TextWrapWord
+
 	connectionCard := sectionCard(
 		sectionHeader("Connection", "Control Discord RPC without exiting the app."),
 		spacer(8),
 		container.NewGridWithColumns(2, disconnectBtn, reconnectBtn),
+		spacer(4),
+		clearPolicyLabel,
+		clearPolicySelect,
+		spacer(4),
+		clientIDLabel,
+		container.NewBorder(nil, nil, nil, clientIDApply, clientIDEntry),
+		assetsHint,
 	)
 
 	// Version footer
@@ -336,6 +498,12 @@ func (ui *AppUI) buildContent() fyne.CanvasObject {
 		spacer(uiSectionGap),
 		privacyCard,
 		spacer(uiSectionGap),
+		ui.buildPrivacyRulesCard(),
+		spacer(uiSectionGap),
+		presenceCard,
+		spacer(uiSectionGap),
+		ui.buildTemplatesCard(),
+		spacer(uiSectionGap),
 		connectionCard,
 		spacer(8),
 		updatesLink,
@@ -343,15 +511,333 @@ func (ui *AppUI) buildContent() fyne.CanvasObject {
 		versionLabel,
 	)
 
-	return container.NewBorder(
+	// Scroll so the settings still fit on small screens as sections are added.
+	return container.NewVScroll(container.NewBorder(
 		spacer(uiOuterPadding),
 		spacer(uiOuterPadding),
 		horizontalSpacer(uiOuterPadding),
 		horizontalSpacer(uiOuterPadding),
 		content,
+	))
+}
+
+// buildTemplatesCard creates the editor for the activity text templates,
+// with a live preview rendered from sample data. Changes apply on Save.
+func (ui *AppUI) buildTemplatesCard() fyne.CanvasObject {
+	drafts := [2]PresenceTemplates{ui.Config.Templates, ui.Config.PrivacyTemplates}
+	current := 0 // 0 edits the normal set, 1 the privacy mode set
+
+	preview := widget.NewLabel("")
+	preview.Wrapping = fyne.TextWrapWord
+	refreshPreview := func() {
+		preview.SetText(drafts[current].preview(current == 1))
+	}
+
+	newTemplateEntry := func(placeholder string, field func(*PresenceTemplates) *string) *widget.Entry {
+		entry := widget.NewEntry()
+		entry.SetPlaceHolder(placeholder)
+		entry.OnChanged = func(text string) {
+			*field(&drafts[current]) = text
+			refreshPreview()
+		}
+		return entry
+	}
+	detailsEntry := newTemplateEntry("{{.Details}}", func(t *PresenceTemplates) *string { return &t.Details })
+	stateEntry := newTemplateEntry("{{.State}}", func(t *PresenceTemplates) *string { return &t.State })
+	largeTextEntry := newTemplateEntry("{{.LargeText}}", func(t *PresenceTemplates) *string { return &t.LargeText })
+	smallTextEntry := newTemplateEntry("{{.SmallText}}", func(t *PresenceTemplates) *string { return &t.SmallText })
+
+	loadDraft := func() {
+		draft := drafts[current]
+		detailsEntry.SetText(draft.Details)
+		stateEntry.SetText(draft.State)
+		largeTextEntry.SetText(draft.LargeText)
+		smallTextEntry.SetText(draft.SmallText)
+		refreshPreview()
+	}
+
+	setSelect := widget.NewSelect([]string{templateSetNormalOption, templateSetPrivacyOption}, func(selected string) {
+		current = 0
+		if selected == templateSetPrivacyOption {
+			current = 1
+		}
+		loadDraft()
+	})
+	setSelect.Selected = templateSetNormalOption
+
+	saveBtn := widget.NewButton("Save", func() {
+		if err := ui.Config.SetTemplates(drafts[0], drafts[1]); err != nil {
+			preview.SetText("Not saved: " + err.Error())
+			return
+		}
+		ui.notifyConfigChanged()
+		refreshPreview()
+	})
+
+	variablesHint := widget.NewLabel("Variables: {{.File}} {{.Mode}} {{.Elapsed}} {{.FileCount}} {{.OtherFiles}} {{.Label}}. Leave empty for the built-in text.")
+	variablesHint.Wrapping = fyne.TextWrapWord
+
+	previewLabel := widget.NewLabel("Preview")
+	previewLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	loadDraft()
+
+	return sectionCard(
+		sectionHeader("Activity Text", "Write your own lines with Go templates."),
+		spacer(8),
+		setSelect,
+		detailsEntry,
+		stateEntry,
+		container.NewGridWithColumns(2, largeTextEntry, smallTextEntry),
+		variablesHint,
+		spacer(4),
+		previewLabel,
+		preview,
+		saveBtn,
+	)
+}
+
+// buildPrivacyRulesCard creates the editor for the per-file privacy rules,
+// with a box that shows what a window title would publish under the rules
+// being edited. Changes apply on Save.
+func (ui *AppUI) buildPrivacyRulesCard() fyne.CanvasObject {
+	rules := append([]PrivacyRule(nil), ui.Config.PrivacyRules...)
+
+	message := widget.NewLabel("")
+	message.Wrapping = fyne.TextWrapWord
+
+	testEntry := widget.NewEntry()
+	testEntry.SetPlaceHolder("Acme Rebrand – Figma")
+	testResult := widget.NewLabel("Enter a window title to see what Discord would show.")
+	testResult.Wrapping = fyne.TextWrapWord
+	// The options are built once per rules change, not on every keystroke.
+	var testOptions presenceOptions
+	var testRulesErr error
+	rebuildTestOptions := func() {
+		testOptions = presenceOptionsFromConfig(ui.Config.Clone())
+		testOptions.PrivacyRules, testRulesErr = compilePrivacyRules(rules)
+	}
+	rebuildTestOptions()
+	refreshTest := func() {
+		if testEntry.Text == "" {
+			return
+		}
+		result := describePrivacyTest(testEntry.Text, testOptions)
+		if testRulesErr != nil {
+			// The test uses the rules that compile; Save rejects the rest.
+			result += "\nSkipped: " + testRulesErr.Error()
+		}
+		testResult.SetText(result)
+	}
+	testEntry.OnChanged = func(string) { refreshTest() }
+
+	rows := container.NewVBox()
+	var refreshRows func()
+	refreshRows = func() {
+		rows.RemoveAll()
+		for i := range rules {
+			rows.Add(ui.privacyRuleRow(rules, i, func(updated []PrivacyRule, relayout bool) {
+				rules = updated
+				if relayout {
+					refreshRows()
+				}
+				rebuildTestOptions()
+				refreshTest()
+			}))
+		}
+		if len(rules) == 0 {
+			rows.Add(widget.NewLabel("No rules. Every file follows Privacy Mode."))
+		}
+		rows.Refresh()
+	}
+	refreshRows()
+
+	addBtn := widget.NewButtonWithIcon("Add Rule", theme.ContentAddIcon(), func() {
+		rules = append(rules, PrivacyRule{Action: privacyActionLabel})
+		refreshRows()
+		rebuildTestOptions()
+		refreshTest()
+	})
+
+	saveBtn := widget.NewButton("Save", func() {
+		if err := ui.Config.SetPrivacyRules(append([]PrivacyRule(nil), rules...)); err != nil {
+			message.SetText("Not saved: " + err.Error())
+			return
+		}
+		message.SetText("")
+		ui.notifyConfigChanged()
+	})
+
+	testLabel := widget.NewLabel("Test a Title")
+	testLabel.TextStyle = fyne.TextStyle{Bold: true}
+
+	return sectionCard(
+		sectionHeader("Privacy Rules", "Match file names by glob or regex; the first matching rule wins."),
+		spacer(8),
+		rows,
+		container.NewGridWithColumns(2, addBtn, saveBtn),
+		message,
+		spacer(4),
+		testLabel,
+		testEntry,
+		testResult,
+	)
+}
+
+// privacyRuleRow builds the editor for rules[i]. onChange receives the
+// updated rules, and whether the rows need rebuilding because rules were
+// moved or removed.
+func (ui *AppUI) privacyRuleRow(rules []PrivacyRule, i int, onChange func(rules []PrivacyRule, relayout bool)) fyne.CanvasObject {
+	matchEntry := widget.NewEntry()
+	matchEntry.SetPlaceHolder("Client *")
+	matchEntry.SetText(rules[i].Match)
+	matchEntry.OnChanged = func(text string) {
+		rules[i].Match = text
+		onChange(rules, false)
+	}
+
+	regexCheck := widget.NewCheck("Regex", func(checked bool) {
+		rules[i].Regex = checked
+		onChange(rules, false)
+	})
+	regexCheck.Checked = rules[i].Regex
+
+	labelEntry := widget.NewEntry()
+	labelEntry.SetPlaceHolder("Label for this rule")
+	labelEntry.SetText(rules[i].Label)
+	labelEntry.OnChanged = func(text string) {
+		rules[i].Label = text
+		onChange(rules, false)
+	}
+
+	actionSelect := widget.NewSelect(privacyActionOptions, func(selected string) {
+		rules[i].Action = privacyActionFromOption(selected)
+		if rules[i].Action == privacyActionCustomLabel {
+			labelEntry.Enable()
+		} else {
+			labelEntry.Disable()
+		}
+		onChange(rules, false)
+	})
+	actionSelect.Selected = privacyActionOptionFromAction(rules[i].Action)
+	if rules[i].Action != privacyActionCustomLabel {
+		labelEntry.Disable()
+	}
+
+	move := func(to int) {
+		if to < 0 || to >= len(rules) {
+			return
+		}
+		rules[i], rules[to] = rules[to], rules[i]
+		onChange(rules, true)
+	}
+	upBtn := widget.NewButtonWithIcon("", theme.MoveUpIcon(), func() { move(i - 1) })
+	downBtn := widget.NewButtonWithIcon("", theme.MoveDownIcon(), func() { move(i + 1) })
+	removeBtn := widget.NewButtonWithIcon("", theme.DeleteIcon(), func() {
+		onChange(append(rules[:i:i], rules[i+1:]...), true)
+	})
+
+	return container.NewVBox(
+		container.NewBorder(nil, nil, widget.NewLabel(fmt.Sprintf("%d.", i+1)), container.NewHBox(regexCheck, upBtn, downBtn, removeBtn), matchEntry),
+		container.NewGridWithColumns(2, actionSelect, labelEntry),
 	)
 }
 
+// describePrivacyTest explains what would be published for a window title.
+func describePrivacyTest(title string, options presenceOptions) string {
+	figma, _ := classifyWindowTitle(title)
+	if figma.Mode == ModeClosed {
+		return "Not a Figma window title."
+	}
+	figma = options.normalizedState(figma)
+
+	decision := options.privacyFor(figma.File)
+	source := "No rule matches; Privacy Mode decides."
+	if decision.Rule >= 0 {
+		source = fmt.Sprintf("Rule %d matches.", decision.Rule+1)
+	}
+	if decision.Action == privacyActionHide {
+		return source + "\nNo presence is shown."
+	}
+
+	activity := activityFromFilename(figma, decision, options, time.Now(), time.Time{})
+	return fmt.Sprintf("%s\nDetails: %s\nState: %s", source, activity.Details, activity.State)
+}
+
+var privacyActionOptions = []string{"Show file name", "Use replacement label", "Use this label", "Hide presence"}
+
+func privacyActionFromOption(option string) string {
+	for i, candidate := range privacyActionOptions {
+		if candidate == option {
+			return privacyActions[i]
+		}
+	}
+	return privacyActionLabel
+}
+
+func privacyActionOptionFromAction(action string) string {
+	for i, candidate := range privacyActions {
+		if candidate == action {
+			return privacyActionOptions[i]
+		}
+	}
+	return privacyActionOptions[1]
+}
+
+const (
+	templateSetNormalOption  = "Normal"
+	templateSetPrivacyOption = "Privacy mode"
+)
+
+const (
+	idleActionShowOption  = "Show Idle"
+	idleActionClearOption = "Clear presence"
+
+	clearPolicyClearOption      = "Clear presence, stay connected"
+	clearPolicyDisconnectOption = "Disconnect from Discord"
+)
+
+var idleTimeoutOptions = []string{"Never", "After 5 min", "After 10 min", "After 15 min", "After 30 min", "After 60 min"}
+
+var timerModeOptions = []string{"Per file", "Per app session", "Per day"}
+
+func timerModeFromOption(option string) string {
+	switch option {
+	case timerModeOptions[1]:
+		return timerModeSession
+	case timerModeOptions[2]:
+		return timerModeDay
+	default:
+		return timerModeFile
+	}
+}
+
+func timerModeOptionFromMode(mode string) string {
+	switch sanitizeTimerMode(mode) {
+	case timerModeSession:
+		return timerModeOptions[1]
+	case timerModeDay:
+		return timerModeOptions[2]
+	default:
+		return timerModeOptions[0]
+	}
+}
+
+func idleTimeoutMinutesFromOption(option string) int {
+	var minutes int
+	if _, err := fmt.Sscanf(option, "After %d min", &minutes); err != nil {
+		return 0
+	}
+	return minutes
+}
+
+func idleTimeoutOptionFromMinutes(minutes int) string {
+	if minutes <= 0 {
+		return idleTimeoutOptions[0]
+	}
+	return fmt.Sprintf("After %d min", minutes)
+}
+
 // setupSystemTray configures the system tray icon and menu.
 func (ui *AppUI) setupSystemTray() {
 	if deskApp, ok := ui.App.(desktop.App); ok {
@@ -360,12 +846,23 @@ func (ui *AppUI) setupSystemTray() {
 		}
 		deskApp.SetSystemTrayWindow(ui.Window)
 
+		ui.trayHeader = fyne.NewMenuItem(ui.Status.label.Text, nil)
+		ui.trayHeader.Disabled = true
+
 		menu := fyne.NewMenu("FigmaRPC",
+			ui.trayHeader,
+			fyne.NewMenuItemSeparator(),
 			fyne.NewMenuItem("Show Settings", func() {
 				ui.Window.Show()
 				ui.Window.RequestFocus()
 			}),
 			fyne.NewMenuItemSeparator(),
+			fyne.NewMenuItem("Pause for 15 Minutes", func() { ui.requestPauseFor("15m") }),
+			fyne.NewMenuItem("Pause for 1 Hour", func() { ui.requestPauseFor("1h") }),
+			fyne.NewMenuItem("Pause Until Tomorrow", func() { ui.requestPauseFor("tomorrow") }),
+			fyne.NewMenuItem("Pause Until Figma Closes", func() { ui.requestPauseFor("figma-closed") }),
+			fyne.NewMenuItem("Resume", func() { ui.requestPause(pauseState{}) }),
+			fyne.NewMenuItemSeparator(),
 			fyne.NewMenuItem("Disconnect from RPC", ui.handleDisconnectAction),
 			fyne.NewMenuItem("Reconnect to RPC", ui.handleReconnectAction),
 			fyne.NewMenuItemSeparator(),
@@ -374,13 +871,76 @@ func (ui *AppUI) setupSystemTray() {
 			}),
 		)
 		deskApp.SetSystemTrayMenu(menu)
+		ui.trayMenu = menu
+	}
+}
+
+// requestPauseFor pauses the presence for a `figma-rpc pause` argument.
+func (ui *AppUI) requestPauseFor(arg string) {
+	pause, err := parsePause(arg, time.Now())
+	if err != nil {
+		fmt.Println("Could not pause:", err)
+		return
+	}
+	ui.requestPause(pause)
+}
+
+// requestPause sends a pause, or with the zero pause a resume, to the RPC
+// loop. A request it hasn't picked up yet is replaced.
+func (ui *AppUI) requestPause(pause pauseState) {
+	select {
+	case <-ui.Events.Pause:
+	default:
+	}
+	select {
+	case ui.Events.Pause <- pause:
+	default:
+	}
+}
+
+// watchStatus renders every status the RPC manager reports, on the Fyne main
+// thread, in the status card and as the tray menu header. While a timed pause
+// runs it is re-rendered every second so the countdown stays current.
+func (ui *AppUI) watchStatus() {
+	countdown := time.NewTicker(time.Second)
+	defer countdown.Stop()
+
+	var current rpcStatus
+	shown := ""
+	for {
+		select {
+		case status, ok := <-ui.Events.Status:
+			if !ok {
+				return
+			}
+			current = status
+		case <-countdown.C:
+			if current.Kind != statusPaused || current.PausedUntil.IsZero() {
+				continue
+			}
+		}
+
+		status := current
+		text := status.Text()
+		if text == shown {
+			continue
+		}
+		shown = text
+		fyne.Do(func() {
+			ui.Status.setStatus(status)
+			if ui.trayMenu != nil {
+				ui.trayHeader.Label = text
+				ui.trayMenu.Refresh()
+			}
+		})
 	}
 }
 
-// notifyConfigChanged sends the current config to the RPC loop (non-blocking).
+// notifyConfigChanged sends a copy of the current config to the RPC loop
+// (non-blocking). The loop owns the copy, so later edits here don't race it.
 func (ui *AppUI) notifyConfigChanged() {
 	select {
-	case ui.Events.ConfigChanged <- ui.Config:
+	case ui.Events.ConfigChanged <- ui.Config.Clone():
 	default:
 	}
 }
@@ -389,8 +949,7 @@ func (ui *AppUI) notifyConfigChanged() {
 // If FirstRun is true, the window is shown; otherwise it starts hidden in the tray.
 func (ui *AppUI) Run() {
 	if ui.Config.FirstRun {
-		ui.Config.FirstRun = false
-		if err := ui.Config.Save(); err != nil {
+		if err := ui.Config.SetFirstRun(false); err != nil {
 			fmt.Println("Error saving first-run flag:", err)
 		}
 		ui.Window.Show()
@@ -0,0 +1,128 @@
+//go:build linux
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// GNOME Shell does not expose other clients' windows on Wayland, so titles are
+// read through the "Window Calls" extension
+// (https://extensions.gnome.org/extension/4724/window-calls/), which publishes
+// the window list on the session bus.
+const (
+	gnomeShellBusName      = "org.gnome.Shell"
+	windowCallsObjectPath  = "/org/gnome/Shell/Extensions/Windows"
+	windowCallsInterface   = "org.gnome.Shell.Extensions.Windows"
+	windowCallsListMethod  = windowCallsInterface + ".List"
+	windowCallsTitleMethod = windowCallsInterface + ".GetTitle"
+)
+
+// windowCallsEntry is one element of the JSON array returned by List.
+// Older extension versions include the title inline; newer ones require GetTitle.
+type windowCallsEntry struct {
+	ID      uint32 `json:"id"`
+	WMClass string `json:"wm_class"`
+	Title   string `json:"title"`
+	Focus   bool   `json:"focus"`
+}
+
+// gnomeShellTitleSource reads titles through the Window Calls extension.
+type gnomeShellTitleSource struct{}
+
+var sessionBus *dbus.Conn
+
+func (gnomeShellTitleSource) Name() string {
+	return "GNOME Shell Window Calls (Wayland)"
+}
+
+func (s gnomeShellTitleSource) Probe() error {
+	_, err := s.Read()
+	return err
+}
+
+func (gnomeShellTitleSource) Read() (TitleResult, error) {
+	windows, err := listGnomeShellFigmaWindows()
+	if err != nil {
+		return TitleResult{}, err
+	}
+	return TitleResult{Windows: windows}, nil
+}
+
+// listGnomeShellFigmaWindows returns the Figma windows known to GNOME Shell,
+// including native Wayland windows that X11 cannot see.
+func listGnomeShellFigmaWindows() ([]FigmaWindow, error) {
+	if sessionBus == nil {
+		conn, err := dbus.ConnectSessionBus()
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to session bus: %w", err)
+		}
+		sessionBus = conn
+	}
+
+	windowCalls := sessionBus.Object(gnomeShellBusName, windowCallsObjectPath)
+
+	var raw string
+	if err := windowCalls.Call(windowCallsListMethod, 0).Store(&raw); err != nil {
+		// A D-Bus error reply, e.g. the extension missing, leaves the bus usable.
+		var dbusErr dbus.Error
+		if !errors.As(err, &dbusErr) {
+			sessionBus.Close()
+			sessionBus = nil
+		}
+		return nil, fmt.Errorf("gnome shell window list failed: %w", err)
+	}
+
+	var entries []windowCallsEntry
+	if err := json.Unmarshal([]byte(raw), &entries); err != nil {
+		return nil, fmt.Errorf("could not parse gnome shell window list: %w", err)
+	}
+
+	windows := make([]FigmaWindow, 0, 4)
+	for _, entry := range entries {
+		title := entry.Title
+		if title == "" {
+			if err := windowCalls.Call(windowCallsTitleMethod, 0, entry.ID).Store(&title); err != nil {
+				continue
+			}
+		}
+		title = strings.TrimSpace(title)
+		if title == "" {
+			continue
+		}
+
+		if isFigmaWindowTitle(title) || isFigmaLinuxClass(entry.WMClass) {
+			windows = append(windows, FigmaWindow{Title: title, Focused: entry.Focus})
+		}
+	}
+
+	return windows, nil
+}
+
+// idleBus is separate from sessionBus because the idle monitor runs on its
+// own goroutine.
+var idleBus *dbus.Conn
+
+// mutterIdleTime asks GNOME's Mutter for the time since the last user input.
+func mutterIdleTime() (time.Duration, error) {
+	if idleBus == nil {
+		conn, err := dbus.ConnectSessionBus()
+		if err != nil {
+			return 0, fmt.Errorf("could not connect to session bus: %w", err)
+		}
+		idleBus = conn
+	}
+
+	var idleMs uint64
+	monitor := idleBus.Object("org.gnome.Mutter.IdleMonitor", "/org/gnome/Mutter/IdleMonitor/Core")
+	if err := monitor.Call("org.gnome.Mutter.IdleMonitor.GetIdletime", 0).Store(&idleMs); err != nil {
+		return 0, fmt.Errorf("mutter idle monitor failed: %w", err)
+	}
+	return time.Duration(idleMs) * time.Millisecond, nil
+}
@@ -3,8 +3,11 @@
 package main
 
 import (
-	"strings"
+	"fmt"
+	"os"
+	"sync"
 	"syscall"
+	"time"
 	"unsafe"
 )
 
@@ -13,52 +16,123 @@ var (
 	user32             = syscall.NewLazyDLL("user32.dll")
 	procEnumWindows    = user32.NewProc("EnumWindows")
 	procGetWindowTextW = user32.NewProc("GetWindowTextW")
+
+	procGetForegroundWindow = user32.NewProc("GetForegroundWindow")
+	procGetLastInputInfo    = user32.NewProc("GetLastInputInfo")
+
+	kernel32          = syscall.NewLazyDLL("kernel32.dll")
+	procGetTickCount  = kernel32.NewProc("GetTickCount")
+	procAttachConsole = kernel32.NewProc("AttachConsole")
 )
 
-// GetFigmaTitle searches all open windows for Figma and returns the active file name.
-func GetFigmaTitle() (string, error) {
-	var fileTitle string
-	var homeTitle string
-
-	// Define the callback function that Windows will call for every window it finds
-	cb := syscall.NewCallback(
-		func(hwnd uintptr, lparam uintptr) uintptr {
-		// 1. Create a buffer to hold the title (256 chars is usually enough)
-		buf := make([]uint16, 256)
-
-		// 2. Read the window title into the buffer
-		ret, _, _ := procGetWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
-
-		if ret > 0 {
-			// Convert UTF-16 buffer to a Go string
-			title := syscall.UTF16ToString(buf)
-
-			// 3. Filter for Figma windows
-			if strings.Contains(title, "Figma") {
-
-				// Priority 1: Check for an actual file (e.g., "Project – Figma")
-				// Note: Figma often uses a specific dash " – ". Copy-paste yours if this fails!
-				if strings.Contains(title, " - Figma") && !strings.Contains(title, "Home") {
-					// Clean up the string to get just the file name
-					fileTitle = strings.TrimSuffix(title, " - Figma")
-					return 0 // Return 0 to STOP searching (we found it!)
-				}
-
-				// Priority 2: Check for the Home screen
-				if strings.Contains(title, "Home - Figma") || strings.Contains(title, "Drafts - Figma") {
-					homeTitle = "Browsing Files"
-				}
-			}
-		}
-		return 1 // Return 1 to CONTINUE searching
-	})
+// attachParentProcess is ATTACH_PARENT_PROCESS, (DWORD)-1.
+const attachParentProcess = ^uint32(0)
+
+// lastInputInfo mirrors the Win32 LASTINPUTINFO struct.
+type lastInputInfo struct {
+	cbSize uint32
+	dwTime uint32
+}
+
+// win32TitleSource enumerates top-level windows with EnumWindows.
+type win32TitleSource struct{}
+
+func init() {
+	RegisterTitleSource(0, win32TitleSource{})
+}
+
+func (win32TitleSource) Name() string {
+	return "Win32 EnumWindows"
+}
+
+func (win32TitleSource) Probe() error {
+	return procEnumWindows.Find()
+}
+
+// enumWindowsCallback is created once: Go allows only a limited number of
+// callbacks per process, and polling would use them up. It collects into
+// enumWindows, which is guarded by enumWindowsMu for the duration of a Read.
+var enumWindowsCallback = syscall.NewCallback(collectFigmaWindow)
+
+var (
+	enumWindowsMu sync.Mutex
+	enumWindows   struct {
+		foreground uintptr
+		result     TitleResult
+	}
+)
+
+// Read searches all open windows and returns the ones that belong to Figma.
+func (win32TitleSource) Read() (TitleResult, error) {
+	enumWindowsMu.Lock()
+	defer enumWindowsMu.Unlock()
+
+	foreground, _, _ := procGetForegroundWindow.Call()
+	enumWindows.foreground = foreground
+	enumWindows.result = TitleResult{}
 
 	// Start the enumeration process
-	procEnumWindows.Call(cb, 0)
+	procEnumWindows.Call(enumWindowsCallback, 0)
 
-	// Return the best match we found
-	if fileTitle != "" {
-		return fileTitle, nil
+	result := enumWindows.result
+	enumWindows.result = TitleResult{}
+	return result, nil
+}
+
+// collectFigmaWindow is called by Windows for every window EnumWindows finds.
+func collectFigmaWindow(hwnd uintptr, lparam uintptr) uintptr {
+	// 1. Create a buffer to hold the title (256 chars is usually enough)
+	buf := make([]uint16, 256)
+
+	// 2. Read the window title into the buffer
+	ret, _, _ := procGetWindowTextW.Call(hwnd, uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+
+	if ret > 0 {
+		// Convert UTF-16 buffer to a Go string
+		title := syscall.UTF16ToString(buf)
+
+		// 3. Keep only Figma windows (e.g. "Project – Figma", "Board – FigJam")
+		if isFigmaWindowTitle(title) {
+			enumWindows.result.Windows = append(enumWindows.result.Windows, FigmaWindow{Title: title, Focused: hwnd == enumWindows.foreground})
+		}
+	}
+	return 1 // Return 1 to CONTINUE searching
+}
+
+// systemIdleTime returns the time since the last keyboard or mouse input.
+func systemIdleTime() (time.Duration, error) {
+	info := lastInputInfo{cbSize: uint32(unsafe.Sizeof(lastInputInfo{}))}
+	ret, _, err := procGetLastInputInfo.Call(uintptr(unsafe.Pointer(&info)))
+	if ret == 0 {
+		return 0, fmt.Errorf("GetLastInputInfo failed: %w", err)
 	}
-	return homeTitle, nil
+
+	// Both values are 32-bit tick counts, so the subtraction handles wraparound.
+	tick, _, _ := procGetTickCount.Call()
+	return time.Duration(uint32(tick)-info.dwTime) * time.Millisecond, nil
+}
+
+// attachParentConsole sends CLI output to the console of the shell that ran
+// the command. Release builds are linked with -H windowsgui, so they get no
+// console of their own and would print nothing. Output redirected to a file
+// or pipe is left alone, and without a parent console, e.g. when started
+// from Explorer, nothing changes.
+func attachParentConsole() {
+	if r, _, _ := procAttachConsole.Call(uintptr(attachParentProcess)); r == 0 {
+		return
+	}
+	console, err := os.OpenFile("CONOUT$", os.O_WRONLY, 0)
+	if err != nil {
+		return
+	}
+	if !validStdHandle(syscall.Stdout) {
+		os.Stdout = console
+	}
+	if !validStdHandle(syscall.Stderr) {
+		os.Stderr = console
+	}
+}
+
+func validStdHandle(handle syscall.Handle) bool {
+	return handle != 0 && handle != syscall.InvalidHandle
 }